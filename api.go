@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	transceivercollector "github.com/wobcom/transceiver-exporter/transceiver-collector"
+)
+
+const apiPath = "/api/v1/transceivers"
+
+// transceiversResponse lists the transceivers read along with the network namespaces which could not be read
+type transceiversResponse struct {
+	Transceivers []transceivercollector.Transceiver    `json:"transceivers"`
+	Errors       []transceivercollector.NamespaceError `json:"errors,omitempty"`
+}
+
+// handleTransceiversRequest serves the decoded transceivers of all monitored interfaces (/api/v1/transceivers) or of a single
+// interface (/api/v1/transceivers/<interface>, the network namespace is selected using the netns query parameter) as JSON
+func handleTransceiversRequest(w http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("Method %s not allowed", request.Method))
+		return
+	}
+
+	ifaceName := strings.TrimPrefix(strings.TrimPrefix(request.URL.Path, apiPath), "/")
+	config := collectorConfig()
+	if len(ifaceName) > 0 {
+		config.InterfacePattern = regexp.MustCompile("^" + regexp.QuoteMeta(ifaceName) + "$")
+	}
+	transceivers, errs := transceivercollector.NewCollector(config).Transceivers()
+	for _, err := range errs {
+		log.Errorf("Error while reading transceivers of network namespace %q: %s", err.Netns, err.Error)
+	}
+	if len(ifaceName) == 0 {
+		writeJSON(w, http.StatusOK, transceiversResponse{transceivers, errs})
+		return
+	}
+
+	netns := request.URL.Query().Get("netns")
+	for _, transceiver := range transceivers {
+		if transceiver.Interface == ifaceName && transceiver.Netns == netns {
+			writeJSON(w, http.StatusOK, transceiver)
+			return
+		}
+	}
+	// the interface may exist in a network namespace which could not be read
+	for _, err := range errs {
+		if err.Netns == netns || (err.Netns == transceivercollector.AllNetworkNamespaces && len(netns) > 0) {
+			writeJSONError(w, http.StatusInternalServerError, fmt.Errorf("Could not read network namespace %q: %s", err.Netns, err.Error))
+			return
+		}
+	}
+	writeJSONError(w, http.StatusNotFound, fmt.Errorf("Interface %s does not exist or is not monitored", ifaceName))
+}
+
+// writeJSON encodes the value before sending the status, so encoding errors are reported as such
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		log.Errorf("Could not encode response: %v", err)
+		body.Reset()
+		status = http.StatusInternalServerError
+		_ = encoder.Encode(map[string]string{"error": "Could not encode response"})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := body.WriteTo(w); err != nil {
+		log.Errorf("Could not write response: %v", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writeJSON(recorder, http.StatusOK, map[string]float64{"value": 1})
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "{\n  \"value\": 1\n}\n" {
+		t.Errorf("writeJSON() = %d %q, expected the encoded value", recorder.Code, recorder.Body.String())
+	}
+
+	// values JSON cannot represent must not be sent with the requested status
+	recorder = httptest.NewRecorder()
+	writeJSON(recorder, http.StatusOK, map[string]float64{"value": math.Inf(-1)})
+	var body map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || recorder.Code != http.StatusInternalServerError || len(body["error"]) == 0 {
+		t.Errorf("writeJSON() of an infinite value = %d %q, expected an error with status 500", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("writeJSON() Content-Type = %q, expected application/json", contentType)
+	}
+}
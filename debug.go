@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	transceivercollector "github.com/wobcom/transceiver-exporter/transceiver-collector"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const eepromDumpPath = "/debug/eeprom/"
+
+// htpasswd maps user names to bcrypt password hashes
+type htpasswd map[string][]byte
+
+// loadHtpasswd reads an htpasswd file. Each non-empty line not starting with '#' has the format `<user>:<bcrypt hash>`
+// (as generated by `htpasswd -nB <user>`).
+func loadHtpasswd(path string) (htpasswd, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not open htpasswd file %s", path)
+	}
+	defer file.Close()
+
+	users := make(htpasswd)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "$2") {
+			return nil, fmt.Errorf("%s:%d: expected `<user>:<bcrypt hash>`", path, lineNumber)
+		}
+		users[fields[0]] = []byte(fields[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Could not read htpasswd file %s", path)
+	}
+	return users, nil
+}
+
+// authenticate checks the basic authentication credentials of a request against the users
+func (h htpasswd) authenticate(request *http.Request) bool {
+	user, password, ok := request.BasicAuth()
+	hash, found := h[user]
+	return ok && found && bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
+
+// protectDebug requires basic authentication by one of the debug users for the handler, if debug users are configured
+func protectDebug(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		configMu.RLock()
+		users := currentSettings.debugUsers
+		configMu.RUnlock()
+		if users != nil && !users.authenticate(request) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="transceiver-exporter debug"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, request)
+	})
+}
+
+// parseEEPROMDumpRequest parses the interface from the path and the memory to dump from the query parameters
+func parseEEPROMDumpRequest(request *http.Request) (transceivercollector.EEPROMDumpRequest, error) {
+	query := request.URL.Query()
+	dump := transceivercollector.EEPROMDumpRequest{
+		Interface:  strings.TrimPrefix(request.URL.Path, eepromDumpPath),
+		Netns:      query.Get("netns"),
+		I2CAddress: transceivercollector.DefaultI2CAddress,
+	}
+	if len(dump.Interface) == 0 {
+		return dump, fmt.Errorf("Missing interface, expected %s<interface>", eepromDumpPath)
+	}
+	parameters := []struct {
+		name  string
+		bits  int
+		paged bool
+		set   func(uint64)
+	}{
+		{"page", 8, true, func(value uint64) { dump.Page = uint8(value) }},
+		{"bank", 8, true, func(value uint64) { dump.Bank = uint8(value) }},
+		{"i2c_address", 7, true, func(value uint64) { dump.I2CAddress = uint8(value) }},
+		{"offset", 32, false, func(value uint64) { dump.Offset = uint32(value) }},
+		{"length", 32, false, func(value uint64) { dump.Length = uint32(value) }},
+	}
+	for _, parameter := range parameters {
+		raw := query.Get(parameter.name)
+		if len(raw) == 0 {
+			continue
+		}
+		// accepts decimal and hexadecimal (0x) values like ethtool(8)
+		value, err := strconv.ParseUint(raw, 0, parameter.bits)
+		if err != nil {
+			return dump, fmt.Errorf("Invalid %s %q", parameter.name, raw)
+		}
+		parameter.set(value)
+		dump.Paged = dump.Paged || parameter.paged
+	}
+	return dump, nil
+}
+
+// handleEEPROMDumpRequest serves the raw module memory of an interface as hex dump or binary (format=binary)
+func handleEEPROMDumpRequest(w http.ResponseWriter, request *http.Request) {
+	dump, err := parseEEPROMDumpRequest(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := request.URL.Query().Get("format")
+	if format != "" && format != "hex" && format != "binary" {
+		http.Error(w, fmt.Sprintf("Unknown format %q, expected hex or binary", format), http.StatusBadRequest)
+		return
+	}
+
+	data, err := transceivercollector.NewCollector(collectorConfig()).DumpEEPROM(dump)
+	if err == transceivercollector.ErrInterfaceNotFound {
+		http.Error(w, fmt.Sprintf("Interface %s does not exist or is not monitored", dump.Interface), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Errorf("Error while dumping EEPROM: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "binary" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", dump.Interface+".bin"))
+		_, _ = w.Write(data)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(formatHexDump(data, dump.Offset)))
+}
+
+// formatHexDump formats data as lines of 16 bytes prefixed with their offset, like `ethtool -m hex on`
+func formatHexDump(data []byte, offset uint32) string {
+	var builder strings.Builder
+	builder.WriteString("Offset\t\tValues\n------\t\t------")
+	for index := 0; index < len(data); index += 16 {
+		end := index + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		line := hex.EncodeToString(data[index:end])
+		bytes := make([]string, 0, 16)
+		for i := 0; i < len(line); i += 2 {
+			bytes = append(bytes, line[i:i+2])
+		}
+		builder.WriteString(fmt.Sprintf("\n0x%04x:\t\t%s", offset+uint32(index), strings.Join(bytes, " ")))
+	}
+	builder.WriteString("\n")
+	return builder.String()
+}
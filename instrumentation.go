@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const exporterPrefix = "transceiver_exporter_"
+
+var (
+	// exporterRegistry holds the metrics about the exporter itself and lives as long as the process
+	exporterRegistry = prometheus.NewRegistry()
+
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: exporterPrefix + "http_requests_total",
+		Help: "Number of HTTP requests by handler and status code",
+	}, []string{"handler", "code"})
+	scrapesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: exporterPrefix + "scrapes_in_flight",
+		Help: "Number of scrapes currently being served",
+	})
+	configLastReloadSuccessful = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: exporterPrefix + "config_last_reload_successful",
+		Help: "Whether the last configuration reload succeeded (1) or failed (0)",
+	})
+	configLastReloadSuccessTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: exporterPrefix + "config_last_reload_success_timestamp_seconds",
+		Help: "Unix time of the last successful configuration (re)load",
+	})
+)
+
+func init() {
+	buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: exporterPrefix + "build_info",
+		Help: "Build information of the running transceiver-exporter binary",
+		ConstLabels: prometheus.Labels{
+			"version":   version,
+			"revision":  getRevision(),
+			"goversion": runtime.Version(),
+		},
+	})
+	buildInfo.Set(1)
+
+	exporterRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		buildInfo,
+		httpRequestsTotal,
+		scrapesInFlight,
+		configLastReloadSuccessful,
+		configLastReloadSuccessTimestamp,
+	)
+}
+
+// getRevision returns the VCS revision the binary was built from
+func getRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return "unknown"
+}
+
+// instrumentHandler counts the requests served by the given handler
+func instrumentHandler(name string, handler http.Handler) http.Handler {
+	return promhttp.InstrumentHandlerCounter(httpRequestsTotal.MustCurryWith(prometheus.Labels{"handler": name}), handler)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const reloadPath = "/-/reload"
+
+// staticOptions are the command line options which only take effect on startup and thus cannot be set in the
+// configuration file. All other options are reloadable.
+var staticOptions = map[string]bool{
+	"version":                            true,
+	"config.file":                        true,
+	"web.listen-address":                 true,
+	"web.shutdown-timeout":               true,
+	"web.config.file":                    true,
+	"web.debug.eeprom":                   true,
+	"web.debug.htpasswd":                 true,
+	"web.enable-lifecycle":               true,
+	"web.telemetry-path":                 true,
+	"collector.carrier-tracker.interval": true,
+	"collector.module-state-file":        true,
+	"collector.topology.interval":        true,
+}
+
+// commandLineOptions are the values of the reloadable options as given on the command line, which apply
+// whenever the configuration file does not set them
+var commandLineOptions map[string]string
+
+// configOption is an option set by the configuration file
+type configOption struct {
+	name  string
+	value string
+	line  int
+}
+
+// loadConfigFile reads the configuration file. Each non-empty line not starting with '#' has the format
+// `<option> [<value>]`, options being named like on the command line (e.g. `include.interfaces swp1,swp2`).
+// Boolean options without value are enabled.
+func loadConfigFile(path string) ([]configOption, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not open configuration file %s", path)
+	}
+	defer file.Close()
+
+	options := []configOption{}
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		field := strings.Fields(line)[0]
+		option := configOption{
+			name:  strings.TrimLeft(field, "-"),
+			value: strings.TrimSpace(strings.TrimPrefix(line, field)),
+			line:  lineNumber,
+		}
+		definition := flag.Lookup(option.name)
+		if definition == nil {
+			return nil, fmt.Errorf("%s:%d: unknown option %s", path, lineNumber, option.name)
+		}
+		if staticOptions[option.name] {
+			return nil, fmt.Errorf("%s:%d: option %s can only be set on the command line", path, lineNumber, option.name)
+		}
+		if boolFlag, ok := definition.Value.(interface{ IsBoolFlag() bool }); ok && boolFlag.IsBoolFlag() && len(option.value) == 0 {
+			option.value = "true"
+		}
+		options = append(options, option)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Could not read configuration file %s", path)
+	}
+	return options, nil
+}
+
+// getOptions returns the current values of the reloadable options
+func getOptions() map[string]string {
+	options := make(map[string]string)
+	flag.VisitAll(func(option *flag.Flag) {
+		if !staticOptions[option.Name] {
+			options[option.Name] = option.Value.String()
+		}
+	})
+	return options
+}
+
+// setOptions sets reloadable options to values previously returned by getOptions
+func setOptions(options map[string]string) {
+	for name, value := range options {
+		_ = flag.Set(name, value)
+	}
+}
+
+// loadConfiguration applies the configuration file on top of the command line options and loads the files referenced
+// by the options. On error the previous configuration stays in effect. The state of the exporter (module ages, module
+// changes, carrier changes, scrape status) is kept.
+func loadConfiguration() error {
+	if err := applyConfiguration(); err != nil {
+		configLastReloadSuccessful.Set(0)
+		return err
+	}
+	configLastReloadSuccessful.Set(1)
+	configLastReloadSuccessTimestamp.SetToCurrentTime()
+	return nil
+}
+
+func applyConfiguration() error {
+	var options []configOption
+	if len(*configFile) > 0 {
+		var err error
+		options, err = loadConfigFile(*configFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	previous := getOptions()
+	// options removed from the configuration file fall back to the command line
+	setOptions(commandLineOptions)
+	for _, option := range options {
+		if err := flag.Set(option.name, option.value); err != nil {
+			setOptions(previous)
+			return fmt.Errorf("%s:%d: invalid value %q for option %s: %v", *configFile, option.line, option.value, option.name, err)
+		}
+	}
+	loaded, err := loadSettings()
+	if err != nil {
+		setOptions(previous)
+		return err
+	}
+	currentSettings = loaded
+	if carrierTracker != nil {
+		carrierTracker.SetInterfaces(splitInterfaceList(*excludeInterfaces), splitInterfaceList(*includeInterfaces), loaded.networkNamespaces)
+		carrierTracker.SetPorts(*breakoutDetection, loaded.portMap)
+	}
+	if remotePowers != nil {
+		remotePowers.SetTopology(loaded.topology, *topologyTimeout)
+	}
+	return nil
+}
+
+// reload reloads the configuration and logs the outcome
+func reload() error {
+	if err := loadConfiguration(); err != nil {
+		log.Errorf("Could not reload configuration, keeping the previous one: %v", err)
+		return err
+	}
+	log.Info("Reloaded configuration")
+	return nil
+}
+
+// handleReloadRequest reloads the configuration like SIGHUP does
+func handleReloadRequest(w http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost && request.Method != http.MethodPut {
+		w.Header().Set("Allow", "POST, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := reload(); err != nil {
+		http.Error(w, fmt.Sprintf("Could not reload configuration: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// handleSignals reloads the configuration on SIGHUP and shuts down on SIGINT or SIGTERM, closing stopped when done
+func handleSignals(server *http.Server, stopped chan<- struct{}) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	for received := range signals {
+		if received == syscall.SIGHUP {
+			_ = reload()
+			continue
+		}
+		log.Infof("Received %v, shutting down", received)
+		// a second signal terminates immediately
+		signal.Stop(signals)
+		shutdown(server)
+		close(stopped)
+		return
+	}
+}
+
+// shutdown stops accepting connections, waits for in-flight requests to finish and stops the background workers
+func shutdown(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Errorf("Could not finish in-flight requests: %v", err)
+	}
+	if carrierTracker != nil {
+		carrierTracker.Stop()
+	}
+	remotePowers.Stop()
+}
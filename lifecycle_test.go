@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfigFile writes a configuration file to a temporary directory and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "transceiver-exporter.conf")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `# comment
+include.interfaces swp1,swp2
+
+  exclude.interfaces-down
+--collector.link-modes.enable false
+collector.driver-stats   rx_crc_errors_phy|rx_symbol_err_phy  
+collector.interface-features.exclude ^tx-checksum .*$
+`)
+	options, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []configOption{
+		{name: "include.interfaces", value: "swp1,swp2", line: 2},
+		// boolean options without value are enabled
+		{name: "exclude.interfaces-down", value: "true", line: 4},
+		// leading dashes are accepted like on the command line
+		{name: "collector.link-modes.enable", value: "false", line: 5},
+		{name: "collector.driver-stats", value: "rx_crc_errors_phy|rx_symbol_err_phy", line: 6},
+		// values extend to the end of the line
+		{name: "collector.interface-features.exclude", value: "^tx-checksum .*$", line: 7},
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("loadConfigFile() = %+v, expected %+v", options, expected)
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"# comment\nunknown.option 1\n", ":2: unknown option unknown.option"},
+		{"web.listen-address [::]:9459\n", ":1: option web.listen-address can only be set on the command line"},
+		{"web.enable-lifecycle\n", ":1: option web.enable-lifecycle can only be set on the command line"},
+		{"config.file other.conf\n", ":1: option config.file can only be set on the command line"},
+		{"collector.topology.interval 1m\n", ":1: option collector.topology.interval can only be set on the command line"},
+	}
+	for _, test := range tests {
+		_, err := loadConfigFile(writeConfigFile(t, test.content))
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("loadConfigFile(%q) error = %v, expected it to contain %q", test.content, err, test.message)
+		}
+	}
+	if _, err := loadConfigFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("loadConfigFile() of a missing file succeeded")
+	}
+}
+
+func TestStaticOptionsExist(t *testing.T) {
+	for name := range staticOptions {
+		if flag.Lookup(name) == nil {
+			t.Errorf("static option %s is not defined", name)
+		}
+	}
+}
@@ -5,25 +5,78 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/exporter-toolkit/web"
 	log "github.com/sirupsen/logrus"
 	transceivercollector "github.com/wobcom/transceiver-exporter/transceiver-collector"
 )
 
 const version string = "1.4.1"
 
+// settings is the state loaded from the reloadable command line options and the files referenced by them
+type settings struct {
+	driverStatsPattern     *regexp.Regexp
+	featuresIncludePattern *regexp.Regexp
+	featuresExcludePattern *regexp.Regexp
+	featuresBaseline       transceivercollector.FeatureBaseline
+	portMap                transceivercollector.PortMap
+	topology               transceivercollector.Topology
+	interfaceLabels        *transceivercollector.InterfaceLabels
+	networkNamespaces      []string
+	debugUsers             htpasswd
+}
+
+var (
+	// configMu guards the reloadable command line options and currentSettings
+	configMu        sync.RWMutex
+	currentSettings settings
+	moduleAges      *transceivercollector.ModuleAgeTracker
+	carrierTracker  *transceivercollector.CarrierTracker
+	remotePowers    *transceivercollector.RemotePowerCache
+	moduleChanges   = transceivercollector.NewModuleChangeTracker()
+	scrapeStatus    = transceivercollector.NewScrapeStatus()
+)
+
 var (
 	showVersion              = flag.Bool("version", false, "Print version and exit")
+	configFile               = flag.String("config.file", "", "Path to a configuration file setting options in addition to the command line (format: <option> [<value>]), reloaded on SIGHUP or POST /-/reload (see -web.enable-lifecycle)")
 	listenAddress            = flag.String("web.listen-address", "[::]:9458", "Address to listen on")
+	shutdownTimeout          = flag.Duration("web.shutdown-timeout", 30*time.Second, "Time to wait for in-flight requests to finish on SIGINT or SIGTERM")
+	webConfigFile            = flag.String("web.config.file", "", "Path to a web configuration file enabling TLS or authentication (exporter-toolkit format)")
+	debugEEPROM              = flag.Bool("web.debug.eeprom", false, "Serve the raw module memory of interfaces at /debug/eeprom/<interface> (e.g. for decoder bug reports)")
+	debugHtpasswdFile        = flag.String("web.debug.htpasswd", "", "Path to an htpasswd file (bcrypt) with the users allowed to access debug endpoints, in addition to the web configuration")
+	enableLifecycle          = flag.Bool("web.enable-lifecycle", false, "Enable reloading the configuration using POST /-/reload (SIGHUP always reloads)")
 	metricsPath              = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics")
 	collectInterfaceFeatures = flag.Bool("collector.interface-features.enable", true, "Collect interface features")
+	featuresInclude          = flag.String("collector.interface-features.include", "", "Regular expression of interface features to export (default all)")
+	featuresExclude          = flag.String("collector.interface-features.exclude", "", "Regular expression of interface features not to export")
+	featuresBaselineFile     = flag.String("collector.interface-features.baseline", "", "Path to a file with the expected state of interface features, only features differing from it are exported (format: <feature> <on|off>)")
+	collectLinkModes         = flag.Bool("collector.link-modes.enable", true, "Collect supported, advertised and link partner advertised link modes")
+	driverStats              = flag.String("collector.driver-stats", "", "Regular expression of driver statistics (ethtool -S) to export, e.g. 'rx_crc_errors_phy|rx_symbol_err_phy' (default none)")
 	excludeInterfaces        = flag.String("exclude.interfaces", "", "Comma seperated list of interfaces to exclude")
 	includeInterfaces        = flag.String("include.interfaces", "", "Comma seperated list of interfaces to include")
 	excludeInterfacesDown    = flag.Bool("exclude.interfaces-down", false, "Don't report on interfaces being management DOWN")
 	powerUnitdBm             = flag.Bool("collector.optical-power-in-dbm", false, "Report optical powers in dBm instead of mW (default false -> mW)")
+	breakoutDetection        = flag.Bool("collector.breakout-detection", true, "Detect breakout netdevs (e.g. swp1s0) sharing a transceiver and export each lane only for the netdev using it")
+	portMapFile              = flag.String("collector.port-map", "", "Path to a file mapping netdevs to physical ports and lanes (format: <interface> <port> [<lanes>])")
+	topologyFile             = flag.String("collector.topology", "", "Path to a file mapping interfaces to remote exporters and interfaces for span loss computation (format: <interface> <remote URL> <remote interface>)")
+	topologyTimeout          = flag.Duration("collector.topology.timeout", 5*time.Second, "Timeout for fetching metrics of remote exporters listed in the topology")
+	topologyInterval         = flag.Duration("collector.topology.interval", 30*time.Second, "Interval of fetching metrics of remote exporters listed in the topology in the background")
+	carrierTrackerInterval   = flag.Duration("collector.carrier-tracker.interval", 0, "Interval of polling carrier changes and rx powers in the background to record the last carrier change and the rx power before it, reads all modules every interval (default disabled)")
+	moduleStateFile          = flag.String("collector.module-state-file", "", "Path to a file persisting when modules were first seen, required for the time in service to survive restarts")
+	namespace                = flag.String("collector.namespace", transceivercollector.DefaultNamespace, "Namespace (prefix) of the exported transceiver metrics")
+	metricSchema             = flag.Int("collector.metric-schema", transceivercollector.MetricSchemaLegacy, "Metric naming schema: 1 (names of version 1.x) or 2 (Prometheus naming conventions, base units)")
+	legacyMetricNames        = flag.Bool("collector.metric-schema.legacy-names", false, "Additionally export the schema 1 names of metrics renamed in schema 2 (for migrating dashboards)")
+	networkNamespaceList     = flag.String("collector.netns", "", "Comma separated list of named network namespaces (see ip netns) to collect in addition to the exporter's own, '*' for all; adds a netns label to all interface metrics")
+	interfaceLabelsFile      = flag.String("collector.interface-labels", "", "Path to a file with static labels attached to all metrics of matching interfaces (format: <interface | /regex/> <label>=<value> ...)")
+	legacyInfoMetrics        = flag.Bool("collector.legacy-info-metrics", true, "Additionally export the separate vendor / identifier / encoding info metrics superseded by transceiver_module_info")
 )
 
 func main() {
@@ -34,9 +87,94 @@ func main() {
 		os.Exit(0)
 	}
 
+	if err := web.Validate(*webConfigFile); err != nil {
+		log.Fatalf("Invalid web configuration: %v", err)
+	}
+
+	commandLineOptions = getOptions()
+	if err := loadConfiguration(); err != nil {
+		log.Fatal(err)
+	}
+
+	var err error
+	moduleAges, err = transceivercollector.NewModuleAgeTracker(*moduleStateFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *carrierTrackerInterval > 0 {
+		carrierTracker = transceivercollector.NewCarrierTracker(*carrierTrackerInterval, splitInterfaceList(*excludeInterfaces), splitInterfaceList(*includeInterfaces), currentSettings.networkNamespaces)
+		carrierTracker.SetPorts(*breakoutDetection, currentSettings.portMap)
+		carrierTracker.Start()
+	}
+	remotePowers = transceivercollector.NewRemotePowerCache(*topologyInterval)
+	remotePowers.SetTopology(currentSettings.topology, *topologyTimeout)
+	remotePowers.Start()
+
 	startServer()
 }
 
+// loadSettings validates the reloadable command line options and loads the files referenced by them
+func loadSettings() (settings, error) {
+	var loaded settings
+	if *metricSchema != transceivercollector.MetricSchemaLegacy && *metricSchema != transceivercollector.MetricSchemaConventional {
+		return loaded, fmt.Errorf("Unknown metric schema %d", *metricSchema)
+	}
+
+	var err error
+	loaded.driverStatsPattern, err = compilePattern(*driverStats)
+	if err != nil {
+		return loaded, errors.Wrapf(err, "Invalid driver statistics pattern")
+	}
+	loaded.featuresIncludePattern, err = compilePattern(*featuresInclude)
+	if err != nil {
+		return loaded, errors.Wrapf(err, "Invalid interface features include pattern")
+	}
+	loaded.featuresExcludePattern, err = compilePattern(*featuresExclude)
+	if err != nil {
+		return loaded, errors.Wrapf(err, "Invalid interface features exclude pattern")
+	}
+	if len(*featuresBaselineFile) > 0 {
+		loaded.featuresBaseline, err = transceivercollector.LoadFeatureBaseline(*featuresBaselineFile)
+		if err != nil {
+			return loaded, err
+		}
+	}
+	if len(*portMapFile) > 0 {
+		loaded.portMap, err = transceivercollector.LoadPortMap(*portMapFile)
+		if err != nil {
+			return loaded, err
+		}
+	}
+	if len(*topologyFile) > 0 {
+		loaded.topology, err = transceivercollector.LoadTopology(*topologyFile)
+		if err != nil {
+			return loaded, err
+		}
+	}
+	if len(*interfaceLabelsFile) > 0 {
+		loaded.interfaceLabels, err = transceivercollector.LoadInterfaceLabels(*interfaceLabelsFile)
+		if err != nil {
+			return loaded, err
+		}
+	}
+	loaded.networkNamespaces = splitInterfaceList(*networkNamespaceList)
+	if len(*debugHtpasswdFile) > 0 {
+		loaded.debugUsers, err = loadHtpasswd(*debugHtpasswdFile)
+		if err != nil {
+			return loaded, err
+		}
+	}
+	return loaded, nil
+}
+
+// compilePattern compiles a regular expression matching whole names, nil if the pattern is empty
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) == 0 {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
 func printVersion() {
 	fmt.Println("transceiver-exporter")
 	fmt.Printf("Version: %s\n", version)
@@ -46,18 +184,26 @@ func printVersion() {
 
 func startServer() {
 	log.Infof("Starting transceiver-exporter (version: %s)\n", version)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte(`<html>
-            <head><title>transceiver-exporter (Version ` + version + `)</title></head>
-            <body>
-            <h1>transceiver-exporter</h1>
-            </body>
-            </html>`))
-	})
-	http.HandleFunc(*metricsPath, handleMetricsRequest)
+	http.Handle("/", instrumentHandler("/", http.HandlerFunc(handleStatusPage)))
+	http.Handle(apiPath, instrumentHandler(apiPath, http.HandlerFunc(handleTransceiversRequest)))
+	http.Handle(apiPath+"/", instrumentHandler(apiPath+"/", http.HandlerFunc(handleTransceiversRequest)))
+	if *debugEEPROM {
+		http.Handle(eepromDumpPath, instrumentHandler(eepromDumpPath, protectDebug(http.HandlerFunc(handleEEPROMDumpRequest))))
+	}
+	if *enableLifecycle {
+		http.Handle(reloadPath, instrumentHandler(reloadPath, http.HandlerFunc(handleReloadRequest)))
+	}
+	http.Handle(*metricsPath, instrumentHandler(*metricsPath, promhttp.InstrumentHandlerInFlight(scrapesInFlight, http.HandlerFunc(handleMetricsRequest))))
 
 	log.Infof("Listening on %s", *listenAddress)
-	log.Fatal(http.ListenAndServe(*listenAddress, nil))
+	server := &http.Server{Addr: *listenAddress}
+	stopped := make(chan struct{})
+	go handleSignals(server, stopped)
+	if err := web.ListenAndServe(server, *webConfigFile, logrusLogger{}); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-stopped
+	log.Info("Stopped transceiver-exporter")
 }
 
 type transceiverCollectorWrapper struct {
@@ -82,24 +228,70 @@ func (t transceiverCollectorWrapper) Describe(ch chan<- *prometheus.Desc) {
 	t.collector.Describe(ch)
 }
 
-func handleMetricsRequest(w http.ResponseWriter, request *http.Request) {
-	var excludedIfaceNames []string
-	var includedIfaceNames []string
-	registry := prometheus.NewRegistry()
+// splitInterfaceList splits a comma separated list of interface names
+func splitInterfaceList(list string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	ifaceNames := strings.Split(list, ",")
+	for index, ifaceName := range ifaceNames {
+		ifaceNames[index] = strings.Trim(ifaceName, " ")
+	}
+	return ifaceNames
+}
 
-	if len(*excludeInterfaces) > 0 {
-		excludedIfaceNames = strings.Split(*excludeInterfaces, ",")
-		for index, excludedIfaceName := range excludedIfaceNames {
-			excludedIfaceNames[index] = strings.Trim(excludedIfaceName, " ")
-		}
+// collectorConfig returns the collector configuration according to the current options
+func collectorConfig() transceivercollector.Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	return transceivercollector.Config{
+		ExcludeInterfaces:         splitInterfaceList(*excludeInterfaces),
+		IncludeInterfaces:         splitInterfaceList(*includeInterfaces),
+		ExcludeInterfacesDown:     *excludeInterfacesDown,
+		CollectInterfaceFeatures:  *collectInterfaceFeatures,
+		InterfaceFeaturesInclude:  currentSettings.featuresIncludePattern,
+		InterfaceFeaturesExclude:  currentSettings.featuresExcludePattern,
+		InterfaceFeaturesBaseline: currentSettings.featuresBaseline,
+		CollectLinkModes:          *collectLinkModes,
+		DriverStatsPattern:        currentSettings.driverStatsPattern,
+		PowerUnitdBm:              *powerUnitdBm,
+		LegacyInfoMetrics:         *legacyInfoMetrics,
+		BreakoutDetection:         *breakoutDetection,
+		PortMap:                   currentSettings.portMap,
+		Topology:                  currentSettings.topology,
+		RemotePowerCache:          remotePowers,
+		ModuleAgeTracker:          moduleAges,
+		CarrierTracker:            carrierTracker,
+		ModuleChangeTracker:       moduleChanges,
+		ScrapeStatus:              scrapeStatus,
+		Namespace:                 *namespace,
+		MetricSchema:              *metricSchema,
+		LegacyMetricNames:         *legacyMetricNames,
+		InterfaceLabels:           currentSettings.interfaceLabels,
+		NetworkNamespaces:         currentSettings.networkNamespaces,
 	}
-	if len(*includeInterfaces) > 0 {
-		includedIfaceNames = strings.Split(*includeInterfaces, ",")
-		for index, includedIfaceName := range includedIfaceNames {
-			includedIfaceNames[index] = strings.Trim(includedIfaceName, " ")
-		}
+}
+
+func handleMetricsRequest(w http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	collectors, err := transceivercollector.ParseCollectorSet(query["collect[]"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	interfacePattern, err := compilePattern(query.Get("interface"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid interface pattern: %v", err), http.StatusBadRequest)
+		return
 	}
-	transceiverCollector := transceivercollector.NewCollector(excludedIfaceNames, includedIfaceNames, *excludeInterfacesDown, *collectInterfaceFeatures, *powerUnitdBm)
+
+	registry := prometheus.NewRegistry()
+
+	config := collectorConfig()
+	config.Collectors = collectors
+	config.InterfacePattern = interfacePattern
+	transceiverCollector := transceivercollector.NewCollector(config)
 	wrapper := &transceiverCollectorWrapper{
 		collector: transceiverCollector,
 	}
@@ -108,7 +300,7 @@ func handleMetricsRequest(w http.ResponseWriter, request *http.Request) {
 	l := log.New()
 	l.Level = log.ErrorLevel
 
-	promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+	promhttp.HandlerFor(prometheus.Gatherers{exporterRegistry, registry}, promhttp.HandlerOpts{
 		ErrorLog:      l,
 		ErrorHandling: promhttp.ContinueOnError,
 	}).ServeHTTP(w, request)
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	transceivercollector "github.com/wobcom/transceiver-exporter/transceiver-collector"
+)
+
+// Threshold states of a measurement, used as CSS classes of the status page
+const (
+	thresholdStateOK      = "ok"
+	thresholdStateWarning = "warning"
+	thresholdStateAlarm   = "alarm"
+	thresholdStateUnknown = "unknown"
+)
+
+// statusPagePort is a row of the status page
+type statusPagePort struct {
+	transceivercollector.Transceiver
+	LastScrape time.Time
+}
+
+type statusPage struct {
+	Version      string
+	MetricsPath  string
+	DebugEEPROM  bool
+	PowerUnitdBm bool
+	Netns        bool
+	ReadAt       time.Time
+	Ports        []statusPagePort
+	Errors       []string
+}
+
+// thresholdState classifies a measurement according to the module's alarm and warning thresholds
+func thresholdState(measurement *transceivercollector.Measurement) string {
+	if measurement == nil || measurement.Thresholds == nil {
+		return thresholdStateUnknown
+	}
+	value, thresholds := measurement.Value, measurement.Thresholds
+	switch {
+	case value >= thresholds.HighAlarm || value <= thresholds.LowAlarm:
+		return thresholdStateAlarm
+	case value >= thresholds.HighWarning || value <= thresholds.LowWarning:
+		return thresholdStateWarning
+	}
+	return thresholdStateOK
+}
+
+func formatMeasurement(measurement *transceivercollector.Measurement, unit string) string {
+	if measurement == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f %s", measurement.Value, unit)
+}
+
+func formatTime(timestamp time.Time) string {
+	if timestamp.IsZero() {
+		return "never"
+	}
+	return timestamp.Format("2006-01-02 15:04:05 MST")
+}
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"state":       thresholdState,
+	"measurement": formatMeasurement,
+	"time":        formatTime,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>transceiver-exporter (Version {{.Version}})</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 3px 6px; text-align: left; vertical-align: top; white-space: nowrap; }
+th { background: #eee; }
+.ok { background: #c8e6c9; }
+.warning { background: #fff59d; }
+.alarm { background: #ef9a9a; }
+.error { color: #b71c1c; }
+.legend span { padding: 2px 6px; margin-right: 4px; }
+</style>
+</head>
+<body>
+<h1>transceiver-exporter</h1>
+<p>
+Version {{.Version}} &middot; <a href="{{.MetricsPath}}">Metrics</a> &middot; <a href="/api/v1/transceivers">JSON API</a>
+&middot; Modules read at {{time .ReadAt}}
+</p>
+<p class="legend"><span class="ok">within thresholds</span><span class="warning">warning</span><span class="alarm">alarm</span><span>no thresholds</span></p>
+{{range .Errors}}<p class="error">{{.}}</p>{{end}}
+<table>
+<tr>
+<th>Interface</th>{{if .Netns}}<th>Netns</th>{{end}}<th>State</th><th>Vendor</th><th>Part number</th><th>Serial number</th>
+<th>Temperature</th><th>Voltage</th><th>Lane</th><th>Tx power</th><th>Rx power</th><th>Last scrape</th>
+</tr>
+{{range .Ports}}
+<tr>
+<td>{{if and $.DebugEEPROM .Module}}<a href="/debug/eeprom/{{.Interface}}{{if .Netns}}?netns={{.Netns}}{{end}}">{{.Interface}}</a>{{else}}{{.Interface}}{{end}}</td>
+{{if $.Netns}}<td>{{.Netns}}</td>{{end}}
+<td{{if .Error}} class="error" title="{{.Error}}"{{end}}>{{.State}}</td>
+{{if .Module}}<td>{{.Module.VendorName}}</td><td>{{.Module.VendorPartNumber}}</td><td>{{.Module.VendorSerialNumber}}</td>{{else}}<td></td><td></td><td></td>{{end}}
+{{if .Monitoring}}
+<td class="{{state .Monitoring.Temperature}}">{{measurement .Monitoring.Temperature "°C"}}</td>
+<td class="{{state .Monitoring.Voltage}}">{{measurement .Monitoring.Voltage "V"}}</td>
+{{else}}<td></td><td></td>{{end}}
+<td>{{range .Lanes}}{{.Index}}<br>{{end}}</td>
+{{if $.PowerUnitdBm}}
+<td>{{range .Lanes}}<span class="{{state .TxPowerDbm}}">{{measurement .TxPowerDbm "dBm"}}</span><br>{{end}}</td>
+<td>{{range .Lanes}}<span class="{{state .RxPowerDbm}}">{{measurement .RxPowerDbm "dBm"}}</span><br>{{end}}</td>
+{{else}}
+<td>{{range .Lanes}}<span class="{{state .TxPower}}">{{measurement .TxPower "mW"}}</span><br>{{end}}</td>
+<td>{{range .Lanes}}<span class="{{state .RxPower}}">{{measurement .RxPower "mW"}}</span><br>{{end}}</td>
+{{end}}
+<td>{{time .LastScrape}}</td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>
+`))
+
+// handleStatusPage serves a human readable overview of all monitored ports as read by the last scrape of all interfaces.
+// It does not read the modules itself, so probing the page causes no I2C traffic.
+func handleStatusPage(w http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(w, request)
+		return
+	}
+
+	config := collectorConfig()
+	page := statusPage{
+		Version:      version,
+		MetricsPath:  *metricsPath,
+		DebugEEPROM:  *debugEEPROM,
+		PowerUnitdBm: config.PowerUnitdBm,
+		Netns:        len(config.NetworkNamespaces) > 0,
+	}
+	transceivers, errs, readAt := scrapeStatus.Transceivers()
+	page.ReadAt = readAt
+	for _, err := range errs {
+		page.Errors = append(page.Errors, fmt.Sprintf("Could not read network namespace %q: %s", err.Netns, err.Error))
+	}
+	for _, transceiver := range transceivers {
+		lastScrape, _ := scrapeStatus.LastSuccess(transceiver.Netns, transceiver.Interface)
+		page.Ports = append(page.Ports, statusPagePort{transceiver, lastScrape})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusPageTemplate.Execute(w, page); err != nil {
+		log.Errorf("Could not render status page: %v", err)
+	}
+}
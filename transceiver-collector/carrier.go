@@ -0,0 +1,277 @@
+package transceivercollector
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wobcom/go-ethtool"
+)
+
+var (
+	carrierChangesDesc              *prometheus.Desc
+	carrierUpChangesDesc            *prometheus.Desc
+	carrierDownChangesDesc          *prometheus.Desc
+	carrierLastChangeDesc           *prometheus.Desc
+	carrierLastChangeRxPowerDescMw  *prometheus.Desc
+	carrierLastChangeRxPowerDescDbm *prometheus.Desc
+)
+
+func init() {
+	carrierChangesDesc = newDesc("carrier_changes_total", "Number of carrier changes of the interface as counted by the kernel", []string{"interface"})
+	carrierUpChangesDesc = newDesc("carrier_up_changes_total", "Number of times the carrier of the interface went up as counted by the kernel", []string{"interface"})
+	carrierDownChangesDesc = newDesc("carrier_down_changes_total", "Number of times the carrier of the interface went down as counted by the kernel", []string{"interface"})
+	carrierLastChangeDesc = newDesc("carrier_last_change_timestamp_seconds", "Unix time of the last carrier change observed by the carrier tracker", []string{"interface"})
+	carrierLastChangeRxPowerDescMw = newDesc("carrier_last_change_rx_power_milliwatts", "Laser rx power in milliwatts last sampled by the carrier tracker before the last carrier change", laserLabels)
+	carrierLastChangeRxPowerDescDbm = newDesc("carrier_last_change_rx_power_dbm", "Laser rx power in dBm last sampled by the carrier tracker before the last carrier change", laserLabels)
+}
+
+// rxPowerSample are the rx powers of the lanes used by an interface
+type rxPowerSample struct {
+	// port is the physical port the module belongs to, see groupInterfacesByPort
+	port string
+	// powers are the rx powers in milliwatts by lane
+	powers map[int]float64
+}
+
+// carrierState is the state of an interface as last seen by the CarrierTracker
+type carrierState struct {
+	changes    uint64
+	rxPowers   *rxPowerSample
+	lastChange time.Time
+	// rx powers sampled before the last change
+	lastChangeRxPowers *rxPowerSample
+}
+
+// CarrierTracker polls the carrier changes of all interfaces in the background in order to record
+// the time of the last carrier change and the rx power sampled right before it
+type CarrierTracker struct {
+	interval time.Duration
+
+	mu                sync.Mutex
+	excludeInterfaces []string
+	includeInterfaces []string
+	networkNamespaces []string
+	breakoutDetection bool
+	portMap           PortMap
+	states            map[scrapeKey]*carrierState
+	stop              chan struct{}
+	done              chan struct{}
+}
+
+// NewCarrierTracker initializes a new CarrierTracker polling the interfaces not excluded every interval.
+// Interfaces of the given named network namespaces are polled as well (see Config.NetworkNamespaces).
+func NewCarrierTracker(interval time.Duration, excludeInterfaces []string, includeInterfaces []string, networkNamespaces []string) *CarrierTracker {
+	return &CarrierTracker{
+		interval:          interval,
+		excludeInterfaces: excludeInterfaces,
+		includeInterfaces: includeInterfaces,
+		networkNamespaces: networkNamespaces,
+		breakoutDetection: true,
+		states:            make(map[scrapeKey]*carrierState),
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+}
+
+// Start starts polling in the background
+func (c *CarrierTracker) Start() {
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			c.poll()
+			select {
+			case <-ticker.C:
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops polling and waits for a running poll to finish
+func (c *CarrierTracker) Stop() {
+	close(c.stop)
+	<-c.done
+}
+
+// SetInterfaces changes the interfaces and network namespaces polled from the next poll on,
+// keeping the state of the interfaces polled so far
+func (c *CarrierTracker) SetInterfaces(excludeInterfaces []string, includeInterfaces []string, networkNamespaces []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.excludeInterfaces = excludeInterfaces
+	c.includeInterfaces = includeInterfaces
+	c.networkNamespaces = networkNamespaces
+}
+
+// SetPorts changes how interfaces are grouped into physical ports (see Config.BreakoutDetection and Config.PortMap),
+// which attributes the lanes of a module to the breakout netdevs using them
+func (c *CarrierTracker) SetPorts(breakoutDetection bool, portMap PortMap) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.breakoutDetection = breakoutDetection
+	c.portMap = portMap
+}
+
+func (c *CarrierTracker) poll() {
+	c.mu.Lock()
+	excludeInterfaces, includeInterfaces, networkNamespaces := c.excludeInterfaces, c.includeInterfaces, c.networkNamespaces
+	breakoutDetection, portMap := c.breakoutDetection, c.portMap
+	c.mu.Unlock()
+
+	namespaces, _ := listNetworkNamespaces(networkNamespaces)
+	for _, netns := range namespaces {
+		netns := netns
+		_ = inNetworkNamespace(netns, func() {
+			c.pollNetworkNamespace(netns, excludeInterfaces, includeInterfaces, breakoutDetection, portMap)
+		})
+	}
+}
+
+func (c *CarrierTracker) pollNetworkNamespace(netns string, excludeInterfaces []string, includeInterfaces []string, breakoutDetection bool, portMap PortMap) {
+	ifaceNames, err := listInterfaces(excludeInterfaces, includeInterfaces, false)
+	if err != nil {
+		return
+	}
+	tool, err := ethtool.NewEthtool()
+	if err != nil {
+		return
+	}
+	defer tool.Close()
+
+	now := time.Now()
+	for _, port := range groupInterfacesByPort(ifaceNames, breakoutDetection, portMap) {
+		// the module is read at most once per port, as soon as one of its netdevs has a carrier
+		var portRxPowers map[int]float64
+		var laserCount int
+		read := false
+		for index, member := range port.members {
+			changes, err := strconv.ParseUint(readSysfsAttribute(member.ifaceName, "carrier_changes"), 10, 64)
+			if err != nil {
+				continue
+			}
+			// the rx power is only sampled while the carrier is up, thus the last sample is the one before the carrier went down
+			var rxPowers *rxPowerSample
+			if readSysfsAttribute(member.ifaceName, "carrier") == "1" {
+				if !read {
+					portRxPowers, laserCount = getRxPowers(tool, port.members[0].ifaceName)
+					read = true
+				}
+				if portRxPowers != nil {
+					rxPowers = &rxPowerSample{port: port.name, powers: selectLanes(portRxPowers, port.lanesFor(index, laserCount))}
+				}
+			}
+			c.update(scrapeKey{netns, member.ifaceName}, changes, rxPowers, now)
+		}
+	}
+}
+
+// selectLanes returns the powers of the given lanes, all powers if lanes is nil
+func selectLanes(powers map[int]float64, lanes []int) map[int]float64 {
+	if lanes == nil {
+		return powers
+	}
+	selected := make(map[int]float64)
+	for _, lane := range lanes {
+		if power, found := powers[lane]; found {
+			selected[lane] = power
+		}
+	}
+	return selected
+}
+
+func (c *CarrierTracker) update(key scrapeKey, changes uint64, rxPowers *rxPowerSample, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, found := c.states[key]
+	if !found {
+		c.states[key] = &carrierState{changes: changes, rxPowers: rxPowers}
+		return
+	}
+	if changes != state.changes {
+		state.changes = changes
+		state.lastChange = now
+		state.lastChangeRxPowers = state.rxPowers
+	}
+	if rxPowers != nil {
+		state.rxPowers = rxPowers
+	}
+}
+
+// getRxPowers reads the rx power in milliwatts of all lasers of an interface's module supporting monitoring,
+// along with the number of lasers
+func getRxPowers(tool *ethtool.Ethtool, ifaceName string) (map[int]float64, int) {
+	iface, err := tool.NewInterface(ifaceName, true)
+	if err != nil || iface == nil || iface.Eeprom == nil || !iface.Eeprom.SupportsMonitoring() {
+		return nil, 0
+	}
+	lasers := iface.Eeprom.GetLasers()
+	rxPowers := make(map[int]float64)
+	for index, laser := range lasers {
+		if !laser.SupportsMonitoring() {
+			continue
+		}
+		rxPower, err := laser.GetRxPower()
+		if err != nil {
+			continue
+		}
+		rxPowers[index] = rxPower.GetValue()
+	}
+	return rxPowers, len(lasers)
+}
+
+// lastChange returns the time of the last observed carrier change and the rx powers sampled before it
+func (c *CarrierTracker) lastChange(netns string, ifaceName string) (time.Time, *rxPowerSample) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, found := c.states[scrapeKey{netns, ifaceName}]
+	if !found {
+		return time.Time{}, nil
+	}
+	return state.lastChange, state.lastChangeRxPowers
+}
+
+// exportCarrier exports the kernel's carrier change counters and the last carrier change observed by the tracker
+func (t *TransceiverCollector) exportCarrier(ifaceName string, ch chan<- prometheus.Metric) {
+	counters := []struct {
+		attribute string
+		desc      *prometheus.Desc
+	}{
+		{"carrier_changes", carrierChangesDesc},
+		{"carrier_up_count", carrierUpChangesDesc},
+		{"carrier_down_count", carrierDownChangesDesc},
+	}
+	for _, counter := range counters {
+		if value, err := strconv.ParseFloat(readSysfsAttribute(ifaceName, counter.attribute), 64); err == nil {
+			ch <- prometheus.MustNewConstMetric(counter.desc, prometheus.CounterValue, value, ifaceName)
+		}
+	}
+
+	if t.carrierTracker == nil {
+		return
+	}
+	lastChange, rxPowers := t.carrierTracker.lastChange(t.netns, ifaceName)
+	if lastChange.IsZero() {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(carrierLastChangeDesc, prometheus.GaugeValue, float64(lastChange.UnixNano())/1e9, ifaceName)
+	if rxPowers == nil {
+		return
+	}
+	for index, rxPower := range rxPowers.powers {
+		if !t.powerUnitdBm {
+			ch <- prometheus.MustNewConstMetric(carrierLastChangeRxPowerDescMw, prometheus.GaugeValue, rxPower, ifaceName, rxPowers.port, strconv.Itoa(index))
+		} else if rxPower > 0 {
+			// 0 mW (dark lane) has no value in dBm
+			ch <- prometheus.MustNewConstMetric(carrierLastChangeRxPowerDescDbm, prometheus.GaugeValue, milliwattsToDbm(rxPower), ifaceName, rxPowers.port, strconv.Itoa(index))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package transceivercollector
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSelectLanes(t *testing.T) {
+	powers := map[int]float64{0: 0.5, 1: 0.4, 2: 0.3, 3: 0}
+	tests := []struct {
+		lanes    []int
+		selected map[int]float64
+	}{
+		{nil, powers},
+		{[]int{1}, map[int]float64{1: 0.4}},
+		{[]int{2, 3}, map[int]float64{2: 0.3, 3: 0}},
+		// lanes without reading are skipped
+		{[]int{4}, map[int]float64{}},
+	}
+	for _, test := range tests {
+		if selected := selectLanes(powers, test.lanes); !reflect.DeepEqual(selected, test.selected) {
+			t.Errorf("selectLanes(%v) = %v, expected %v", test.lanes, selected, test.selected)
+		}
+	}
+}
+
+// collectLastChangeRxPowers runs exportCarrier for an interface not present on the host and returns the exported
+// rx powers of the last carrier change by port and laser index
+func collectLastChangeRxPowers(t *testing.T, collector *TransceiverCollector, ifaceName string) map[string]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 100)
+	collector.exportCarrier(ifaceName, ch)
+	close(ch)
+
+	powers := map[string]float64{}
+	for metric := range ch {
+		if metric.Desc() != carrierLastChangeRxPowerDescMw && metric.Desc() != carrierLastChangeRxPowerDescDbm {
+			continue
+		}
+		var written dto.Metric
+		if err := metric.Write(&written); err != nil {
+			t.Fatal(err)
+		}
+		labels := map[string]string{}
+		for _, label := range written.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		powers[labels["port"]+"/"+labels["laser_index"]] = roundDecibels(written.GetGauge().GetValue())
+	}
+	return powers
+}
+
+func TestCarrierTrackerLastChange(t *testing.T) {
+	tracker := NewCarrierTracker(time.Minute, nil, nil, nil)
+	now := time.Now()
+	key := scrapeKey{"", "test-swp1s1"}
+	tracker.update(key, 1, &rxPowerSample{port: "swp1", powers: map[int]float64{1: 0.5}}, now)
+	tracker.update(key, 1, &rxPowerSample{port: "swp1", powers: map[int]float64{1: 0}}, now.Add(time.Second))
+	// the carrier went down, the rx power is not sampled
+	tracker.update(key, 2, nil, now.Add(2*time.Second))
+
+	lastChange, rxPowers := tracker.lastChange("", "test-swp1s1")
+	if !lastChange.Equal(now.Add(2 * time.Second)) {
+		t.Errorf("lastChange() = %v, expected the time of the poll observing the change", lastChange)
+	}
+	if rxPowers == nil || rxPowers.port != "swp1" || !reflect.DeepEqual(rxPowers.powers, map[int]float64{1: 0}) {
+		t.Errorf("lastChange() rx powers = %+v, expected the last sample before the change", rxPowers)
+	}
+
+	collector := &TransceiverCollector{carrierTracker: tracker}
+	if powers := collectLastChangeRxPowers(t, collector, "test-swp1s1"); !reflect.DeepEqual(powers, map[string]float64{"swp1/1": 0}) {
+		t.Errorf("exported rx powers in mW %v, expected lane 1 of port swp1", powers)
+	}
+	// 0 mW (dark lane) cannot be expressed in dBm
+	collector.powerUnitdBm = true
+	if powers := collectLastChangeRxPowers(t, collector, "test-swp1s1"); len(powers) != 0 {
+		t.Errorf("exported rx powers in dBm %v, expected none for a dark lane", powers)
+	}
+}
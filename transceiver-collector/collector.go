@@ -3,11 +3,12 @@ package transceivercollector
 import (
 	"fmt"
 	"net"
+	"regexp"
 	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
-	log "github.com/sirupsen/logrus"
 	"github.com/wobcom/go-ethtool"
 	"github.com/wobcom/go-ethtool/eeprom"
 )
@@ -24,6 +25,7 @@ var (
 	interfaceFeatureActiveDesc    *prometheus.Desc
 	interfaceFeatureAvailableDesc *prometheus.Desc
 
+	moduleInfoDesc                            *prometheus.Desc
 	identifierDesc                            *prometheus.Desc
 	encodingDesc                              *prometheus.Desc
 	powerClassDesc                            *prometheus.Desc
@@ -51,6 +53,8 @@ var (
 	moduleVoltageLowAlarmThresholdDesc        *prometheus.Desc
 	moduleVoltageLowWarningThresholdDesc      *prometheus.Desc
 
+	laserWavelengthDesc *prometheus.Desc
+
 	/* Laser monitoring information */
 	laserSupportsMonitoringDesc *prometheus.Desc
 
@@ -86,7 +90,7 @@ var (
 	laserRxPowerLowWarningThresholdDescDbm  *prometheus.Desc
 )
 
-var laserLabels = []string{"interface", "laser_index"}
+var laserLabels = []string{"interface", "port", "laser_index"}
 
 // TransceiverCollector implements prometheus.Collector interface and collects various interface statistics
 type TransceiverCollector struct {
@@ -94,7 +98,78 @@ type TransceiverCollector struct {
 	includeInterfaces        []string
 	excludeInterfacesDown    bool
 	collectInterfaceFeatures bool
+	featureFilter            featureFilter
+	collectLinkModes         bool
+	driverStatsPattern       *regexp.Regexp
 	powerUnitdBm             bool
+	legacyInfoMetrics        bool
+	breakoutDetection        bool
+	portMap                  PortMap
+	topology                 Topology
+	remotePowerCache         *RemotePowerCache
+	moduleAgeTracker         *ModuleAgeTracker
+	carrierTracker           *CarrierTracker
+	moduleChangeTracker      *ModuleChangeTracker
+	scrapeStatus             *ScrapeStatus
+	translator               *metricTranslator
+	networkNamespaces        []string
+	collectors               CollectorSet
+	interfacePattern         *regexp.Regexp
+	// transceivers decoded during the scrape, nil unless all interfaces are scraped
+	snapshot *transceiverSnapshot
+	// network namespace being collected, empty for the exporter's own
+	netns string
+
+	linkModeNames []string
+}
+
+// Config configures a TransceiverCollector
+type Config struct {
+	ExcludeInterfaces        []string
+	IncludeInterfaces        []string
+	ExcludeInterfacesDown    bool
+	CollectInterfaceFeatures bool
+	// InterfaceFeaturesInclude selects the interface features to export by name, all if nil
+	InterfaceFeaturesInclude *regexp.Regexp
+	// InterfaceFeaturesExclude excludes interface features by name, none if nil
+	InterfaceFeaturesExclude *regexp.Regexp
+	// InterfaceFeaturesBaseline restricts the interface features exported to those whose active state differs from the baseline
+	InterfaceFeaturesBaseline FeatureBaseline
+	// CollectLinkModes exports the supported, advertised and link partner advertised link modes
+	CollectLinkModes bool
+	// DriverStatsPattern selects the driver statistics (ethtool -S) to export by name, none are exported if nil
+	DriverStatsPattern *regexp.Regexp
+	PowerUnitdBm       bool
+	LegacyInfoMetrics  bool
+	BreakoutDetection  bool
+	PortMap            PortMap
+	// Topology maps interfaces to remote ports in order to compute the span loss
+	Topology Topology
+	// RemotePowerCache fetches the remote exporters of the topology, the span loss is not exported if nil
+	RemotePowerCache *RemotePowerCache
+	// ModuleAgeTracker remembers when modules were first seen, the time in service is not exported if nil
+	ModuleAgeTracker *ModuleAgeTracker
+	// CarrierTracker records the last carrier change, which is not exported if nil
+	CarrierTracker *CarrierTracker
+	// ModuleChangeTracker detects module insertions, removals and swaps, which are not exported if nil
+	ModuleChangeTracker *ModuleChangeTracker
+	// ScrapeStatus keeps track of errors across scrapes, a new one is created if nil
+	ScrapeStatus *ScrapeStatus
+	// Namespace metrics are exported with, DefaultNamespace if empty
+	Namespace string
+	// MetricSchema selects the metric names, MetricSchemaLegacy if 0
+	MetricSchema int
+	// LegacyMetricNames additionally exports the schema 1 names of renamed metrics when using MetricSchemaConventional
+	LegacyMetricNames bool
+	// InterfaceLabels are attached to all metrics of matching interfaces
+	InterfaceLabels *InterfaceLabels
+	// NetworkNamespaces are the named network namespaces collected in addition to the exporter's own, all if it
+	// contains AllNetworkNamespaces. If not empty, all metrics of interfaces carry a netns label.
+	NetworkNamespaces []string
+	// Collectors restricts the metric groups collected, all if nil
+	Collectors CollectorSet
+	// InterfacePattern restricts the interfaces collected by name in addition to the include and exclude lists, all if nil
+	InterfacePattern *regexp.Regexp
 }
 
 type measurementDesc struct {
@@ -108,6 +183,7 @@ type measurementDesc struct {
 
 type measurementDescLightLevels struct {
 	ThresholdsSupportedDesc *prometheus.Desc
+	MarginDesc              *prometheus.Desc
 
 	ValueDescMw                 *prometheus.Desc
 	ThresholdsHighAlarmDescMw   *prometheus.Desc
@@ -125,90 +201,117 @@ type measurementDescLightLevels struct {
 func init() {
 	interfaceLabels := []string{"interface"}
 
-	driverDesc = prometheus.NewDesc(prefix+"driver_name_info", "Driver name", []string{"interface", "driver_name"}, nil)
-	driverVersionDesc = prometheus.NewDesc(prefix+"driver_version_info", "Driver version", []string{"interface", "driver_version"}, nil)
-	firmwareVersionDesc = prometheus.NewDesc(prefix+"firmware_version_info", "Firmware version", []string{"interface", "firmware_version"}, nil)
-	busInfoDesc = prometheus.NewDesc(prefix+"bus_info", "Bus information", []string{"interface", "bus_information"}, nil)
-	expansionRomVersionDesc = prometheus.NewDesc(prefix+"expansion_rom_version_info", "Expansion ROM Version", []string{"interface", "expansion_rom_version"}, nil)
-
-	interfaceFeatureActiveDesc = prometheus.NewDesc(prefix+"interface_feature_active", "Interfaces features as reported by interface driver. 1 if active.", []string{"interface", "feature_name"}, nil)
-	interfaceFeatureAvailableDesc = prometheus.NewDesc(prefix+"interface_feature_available", "Interfaces features as reported by interface driver. 1 if available.", []string{"interface", "feature_name"}, nil)
-
-	identifierDesc = prometheus.NewDesc(prefix+"identifier_info", "Type of transceiver information", []string{"interface", "identifier"}, nil)
-	encodingDesc = prometheus.NewDesc(prefix+"encoding_info", "Transceiver encoding information", []string{"interface", "encoding"}, nil)
-	powerClassDesc = prometheus.NewDesc(prefix+"powerclass_info", "Highest power class supported by the transceiver", interfaceLabels, nil)
-	powerClassWattageDesc = prometheus.NewDesc(prefix+"powerclass_watts", "Maximum wattage supported by the transceivers power class", interfaceLabels, nil)
-	signalingRateDesc = prometheus.NewDesc(prefix+"signalingrate_bauds_per_second", "Signaling rate in bauds per second supported by the transceiver", interfaceLabels, nil)
-	supportedLinkLengthsDesc = prometheus.NewDesc(prefix+"supported_link_length_meter", "Maximum supported link length for different media in meters", []string{"interface", "media"}, nil)
-	vendorNameDesc = prometheus.NewDesc(prefix+"vendor_name_info", "Vendor name", []string{"interface", "vendor_name"}, nil)
-	vendorPNDesc = prometheus.NewDesc(prefix+"vendor_part_number_info", "Vendor part number", []string{"interface", "vendor_part_number"}, nil)
-	vendorRevDesc = prometheus.NewDesc(prefix+"vendor_revision_info", "Vendor revision", []string{"interface", "vendor_revision"}, nil)
-	vendorSNDesc = prometheus.NewDesc(prefix+"vendor_serial_number_info", "Vendor serial number", []string{"interface", "vendor_serial_number"}, nil)
-	vendorOUIDesc = prometheus.NewDesc(prefix+"vendor_oui_info", "Vendor IEE company ID", []string{"interface", "vendor_oui"}, nil)
-	dateCodeDesc = prometheus.NewDesc(prefix+"date_code_unix_time", "Vendor supplied date code exported as unix epoch", interfaceLabels, nil)
-	wavelengthDesc = prometheus.NewDesc(prefix+"wavelength_nanometer", "Wavelength in nanometers", interfaceLabels, nil)
-	moduleSupportsMonitoringDesc = prometheus.NewDesc(prefix+"module_supports_monitoring_bool", "1 if the module supports real time monitoring", interfaceLabels, nil)
-
-	moduleTemperatureDesc = prometheus.NewDesc(prefix+"module_temperature_degrees_celsius", "Module temperature in degrees celsius", interfaceLabels, nil)
-	moduleTemperatureThresholdsSupportedDesc = prometheus.NewDesc(prefix+"module_temperature_supports_thresholds_bool", "1 if thresholds for module temperature are supported", interfaceLabels, nil)
-	moduleTemperatureHighAlarmThresholdDesc = prometheus.NewDesc(prefix+"module_temperature_high_alarm_threshold_degrees_celsius", "High alarm threshold for the module temperature in degrees celsius", interfaceLabels, nil)
-	moduleTemperatureHighWarningThresholdDesc = prometheus.NewDesc(prefix+"module_temperature_high_warning_threshold_degrees_celsius", "High warning threshold for the module temperature in degrees celsius", interfaceLabels, nil)
-	moduleTemperatureLowAlarmThresholdDesc = prometheus.NewDesc(prefix+"module_temperature_low_alarm_threshold_degrees_celsius", "Low alarm threshold for the module temperature in degrees celsius", interfaceLabels, nil)
-	moduleTemperatureLowWarningThresholdDesc = prometheus.NewDesc(prefix+"module_temperature_low_warning_threshold_degrees_celsius", "Low warning threshold for the module temperature in degrees celsius", interfaceLabels, nil)
-
-	moduleVoltageDesc = prometheus.NewDesc(prefix+"module_voltage_volts", "Module supply voltage in Volts", interfaceLabels, nil)
-	moduleVoltageThresholdsSupportedDesc = prometheus.NewDesc(prefix+"module_voltage_supports_thresholds_bool", "1 if thresholds for modue voltage are supported", interfaceLabels, nil)
-	moduleVoltageHighAlarmThresholdDesc = prometheus.NewDesc(prefix+"module_voltage_high_alarm_threshold_voltage", "High alarm threshold for the module voltage in volts", interfaceLabels, nil)
-	moduleVoltageHighWarningThresholdDesc = prometheus.NewDesc(prefix+"module_voltage_high_warning_threshold_voltage", "High warning threshold for the module voltage in volts", interfaceLabels, nil)
-	moduleVoltageLowAlarmThresholdDesc = prometheus.NewDesc(prefix+"module_voltage_low_alarm_threshold_voltage", "Low alarm threshold for the module voltage in volts", interfaceLabels, nil)
-	moduleVoltageLowWarningThresholdDesc = prometheus.NewDesc(prefix+"module_voltage_low_warning_threshold_voltage", "Low warning threshold for the module voltage in volts", interfaceLabels, nil)
+	driverDesc = newDesc("driver_name_info", "Driver name", []string{"interface", "driver_name"})
+	driverVersionDesc = newDesc("driver_version_info", "Driver version", []string{"interface", "driver_version"})
+	firmwareVersionDesc = newDesc("firmware_version_info", "Firmware version", []string{"interface", "firmware_version"})
+	busInfoDesc = newDesc("bus_info", "Bus information", []string{"interface", "bus_information"})
+	expansionRomVersionDesc = newDesc("expansion_rom_version_info", "Expansion ROM Version", []string{"interface", "expansion_rom_version"})
+
+	interfaceFeatureActiveDesc = newDesc("interface_feature_active", "Interfaces features as reported by interface driver. 1 if active.", []string{"interface", "feature_name"})
+	interfaceFeatureAvailableDesc = newDesc("interface_feature_available", "Interfaces features as reported by interface driver. 1 if available.", []string{"interface", "feature_name"})
+
+	moduleInfoDesc = newDesc("module_info", "Transceiver identity information", []string{"interface", "vendor", "part_number", "revision", "serial", "oui", "identifier", "encoding", "connector"})
+	identifierDesc = newDesc("identifier_info", "Type of transceiver information", []string{"interface", "identifier"})
+	encodingDesc = newDesc("encoding_info", "Transceiver encoding information", []string{"interface", "encoding"})
+	powerClassDesc = newDesc("powerclass_info", "Highest power class supported by the transceiver", interfaceLabels)
+	powerClassWattageDesc = newDesc("powerclass_watts", "Maximum wattage supported by the transceivers power class", interfaceLabels)
+	signalingRateDesc = newDesc("signalingrate_bauds_per_second", "Signaling rate in bauds per second supported by the transceiver", interfaceLabels)
+	supportedLinkLengthsDesc = newDesc("supported_link_length_meter", "Maximum supported link length for different media in meters", []string{"interface", "media"})
+	vendorNameDesc = newDesc("vendor_name_info", "Vendor name", []string{"interface", "vendor_name"})
+	vendorPNDesc = newDesc("vendor_part_number_info", "Vendor part number", []string{"interface", "vendor_part_number"})
+	vendorRevDesc = newDesc("vendor_revision_info", "Vendor revision", []string{"interface", "vendor_revision"})
+	vendorSNDesc = newDesc("vendor_serial_number_info", "Vendor serial number", []string{"interface", "vendor_serial_number"})
+	vendorOUIDesc = newDesc("vendor_oui_info", "Vendor IEE company ID", []string{"interface", "vendor_oui"})
+	dateCodeDesc = newDesc("date_code_unix_time", "Vendor supplied date code exported as unix epoch", interfaceLabels)
+	wavelengthDesc = newDesc("wavelength_nanometer", "Wavelength in nanometers", interfaceLabels)
+	moduleSupportsMonitoringDesc = newDesc("module_supports_monitoring_bool", "1 if the module supports real time monitoring", interfaceLabels)
+
+	moduleTemperatureDesc = newDesc("module_temperature_degrees_celsius", "Module temperature in degrees celsius", interfaceLabels)
+	moduleTemperatureThresholdsSupportedDesc = newDesc("module_temperature_supports_thresholds_bool", "1 if thresholds for module temperature are supported", interfaceLabels)
+	moduleTemperatureHighAlarmThresholdDesc = newDesc("module_temperature_high_alarm_threshold_degrees_celsius", "High alarm threshold for the module temperature in degrees celsius", interfaceLabels)
+	moduleTemperatureHighWarningThresholdDesc = newDesc("module_temperature_high_warning_threshold_degrees_celsius", "High warning threshold for the module temperature in degrees celsius", interfaceLabels)
+	moduleTemperatureLowAlarmThresholdDesc = newDesc("module_temperature_low_alarm_threshold_degrees_celsius", "Low alarm threshold for the module temperature in degrees celsius", interfaceLabels)
+	moduleTemperatureLowWarningThresholdDesc = newDesc("module_temperature_low_warning_threshold_degrees_celsius", "Low warning threshold for the module temperature in degrees celsius", interfaceLabels)
+
+	moduleVoltageDesc = newDesc("module_voltage_volts", "Module supply voltage in Volts", interfaceLabels)
+	moduleVoltageThresholdsSupportedDesc = newDesc("module_voltage_supports_thresholds_bool", "1 if thresholds for modue voltage are supported", interfaceLabels)
+	moduleVoltageHighAlarmThresholdDesc = newDesc("module_voltage_high_alarm_threshold_voltage", "High alarm threshold for the module voltage in volts", interfaceLabels)
+	moduleVoltageHighWarningThresholdDesc = newDesc("module_voltage_high_warning_threshold_voltage", "High warning threshold for the module voltage in volts", interfaceLabels)
+	moduleVoltageLowAlarmThresholdDesc = newDesc("module_voltage_low_alarm_threshold_voltage", "Low alarm threshold for the module voltage in volts", interfaceLabels)
+	moduleVoltageLowWarningThresholdDesc = newDesc("module_voltage_low_warning_threshold_voltage", "Low warning threshold for the module voltage in volts", interfaceLabels)
+
+	laserWavelengthDesc = newDesc("laser_wavelength_nanometer", "Nominal wavelength of the laser in nanometers", laserLabels)
 
 	/* Laser monitoring information */
-	laserSupportsMonitoringDesc = prometheus.NewDesc(prefix+"laser_supports_monitoring_bool", "1 if the laser supports real time monitoring", laserLabels, nil)
-	laserBiasDesc = prometheus.NewDesc(prefix+"laser_bias_current_milliamperes", "Laser bias current in in milliamperes", laserLabels, nil)
-	laserBiasThresholdsSupportedDesc = prometheus.NewDesc(prefix+"laser_bias_current_supports_thresholds_bool", "1 if thresholds for the laser bias current are supported", laserLabels, nil)
-	laserBiasHighAlarmThresholdDesc = prometheus.NewDesc(prefix+"laser_bias_current_high_alarm_threshold_milliamperes", "High alarm threshold for the laser bias current in milliamperes", laserLabels, nil)
-	laserBiasHighWarningThresholdDesc = prometheus.NewDesc(prefix+"laser_bias_current_high_warning_threshold_milliamperes", "High warning threshold for the laser bias current in milliamperes", laserLabels, nil)
-	laserBiasLowAlarmThresholdDesc = prometheus.NewDesc(prefix+"laser_bias_current_low_alarm_threshold_milliamperes", "Low alarm threshold for the laser bias current in milliamperes", laserLabels, nil)
-	laserBiasLowWarningThresholdDesc = prometheus.NewDesc(prefix+"laser_bias_current_low_warning_threshold_milliamperes", "Low warning threshold for the laser bias current in milliamperes", laserLabels, nil)
+	laserSupportsMonitoringDesc = newDesc("laser_supports_monitoring_bool", "1 if the laser supports real time monitoring", laserLabels)
+	laserBiasDesc = newDesc("laser_bias_current_milliamperes", "Laser bias current in in milliamperes", laserLabels)
+	laserBiasThresholdsSupportedDesc = newDesc("laser_bias_current_supports_thresholds_bool", "1 if thresholds for the laser bias current are supported", laserLabels)
+	laserBiasHighAlarmThresholdDesc = newDesc("laser_bias_current_high_alarm_threshold_milliamperes", "High alarm threshold for the laser bias current in milliamperes", laserLabels)
+	laserBiasHighWarningThresholdDesc = newDesc("laser_bias_current_high_warning_threshold_milliamperes", "High warning threshold for the laser bias current in milliamperes", laserLabels)
+	laserBiasLowAlarmThresholdDesc = newDesc("laser_bias_current_low_alarm_threshold_milliamperes", "Low alarm threshold for the laser bias current in milliamperes", laserLabels)
+	laserBiasLowWarningThresholdDesc = newDesc("laser_bias_current_low_warning_threshold_milliamperes", "Low warning threshold for the laser bias current in milliamperes", laserLabels)
+
+	laserTxPowerThresholdsSupportedDesc = newDesc("laser_tx_power_supports_thresholds_bool", "1 if thresholds for the laser tx power are supported", laserLabels)
+	laserRxPowerThresholdsSupportedDesc = newDesc("laser_rx_power_supports_thresholds_bool", "1 if thresholds for the laser rx power are supported", laserLabels)
+
+	laserTxPowerDescDbm = newDesc("laser_tx_power_dbm", "Laser tx power in dBm", laserLabels)
+	laserTxPowerHighAlarmThresholdDescDbm = newDesc("laser_tx_power_high_alarm_threshold_dbm", "High alarm threshold for the laser tx power in dBm", laserLabels)
+	laserTxPowerHighWarningThresholdDescDbm = newDesc("laser_tx_power_high_warning_threshold_dbm", "High warning threshold for the laser tx power in dBm", laserLabels)
+	laserTxPowerLowAlarmThresholdDescDbm = newDesc("laser_tx_power_low_alarm_threshold_dbm", "Low alarm threshold for the laser tx power in dBm", laserLabels)
+	laserTxPowerLowWarningThresholdDescDbm = newDesc("laser_tx_power_low_warning_threshold_dbm", "Low warning threshold for the laser tx power in dBm", laserLabels)
+
+	laserRxPowerDescDbm = newDesc("laser_rx_power_dbm", "Laser rx power in dBm", laserLabels)
+	laserRxPowerHighAlarmThresholdDescDbm = newDesc("laser_rx_power_high_alarm_threshold_dbm", "High alarm threshold for the laser rx power in dBm", laserLabels)
+	laserRxPowerHighWarningThresholdDescDbm = newDesc("laser_rx_power_high_warning_threshold_dbm", "High warning threshold for the laser rx power in dBm", laserLabels)
+	laserRxPowerLowAlarmThresholdDescDbm = newDesc("laser_rx_power_low_alarm_threshold_dbm", "Low alarm threshold for the laser rx power in dBm", laserLabels)
+	laserRxPowerLowWarningThresholdDescDbm = newDesc("laser_rx_power_low_warning_threshold_dbm", "Low warning threshold for the laser rx power in dBm", laserLabels)
+
+	laserTxPowerDescMw = newDesc("laser_tx_power_milliwatts", "Laser tx power in milliwatts", laserLabels)
+	laserTxPowerHighAlarmThresholdDescMw = newDesc("laser_tx_power_high_alarm_threshold_milliwatts", "High alarm threshold for the laser tx power in milliwatts", laserLabels)
+	laserTxPowerHighWarningThresholdDescMw = newDesc("laser_tx_power_high_warning_threshold_milliwatts", "High warning threshold for the laser tx power in milliwatts", laserLabels)
+	laserTxPowerLowAlarmThresholdDescMw = newDesc("laser_tx_power_low_alarm_threshold_milliwatts", "Low alarm threshold for the laser tx power in milliwatts", laserLabels)
+	laserTxPowerLowWarningThresholdDescMw = newDesc("laser_tx_power_low_warning_threshold_milliwatts", "Low warning threshold for the laser tx power in milliwatts", laserLabels)
+
+	laserRxPowerDescMw = newDesc("laser_rx_power_milliwatts", "Laser rx power in milliwatts", laserLabels)
+	laserRxPowerHighAlarmThresholdDescMw = newDesc("laser_rx_power_high_alarm_threshold_milliwatts", "High alarm threshold for the laser rx power in milliwatts", laserLabels)
+	laserRxPowerHighWarningThresholdDescMw = newDesc("laser_rx_power_high_warning_threshold_milliwatts", "High warning threshold for the laser rx power in milliwatts", laserLabels)
+	laserRxPowerLowAlarmThresholdDescMw = newDesc("laser_rx_power_low_alarm_threshold_milliwatts", "Low alarm threshold for the laser rx power in milliwatts", laserLabels)
+	laserRxPowerLowWarningThresholdDescMw = newDesc("laser_rx_power_low_warning_threshold_milliwatts", "Low warning threshold for the laser rx power in milliwatts", laserLabels)
 }
 
 // NewCollector initializes a new TransceiverCollector
-func NewCollector(excludeInterfaces []string, includeInterfaces []string, excludeInterfacesDown bool, collectInterfaceFeatures bool, powerUnitdBm bool) *TransceiverCollector {
-	laserTxPowerThresholdsSupportedDesc = prometheus.NewDesc(prefix+"laser_tx_power_supports_thresholds_bool", "1 if thresholds for the laser tx power are supported", laserLabels, nil)
-	laserRxPowerThresholdsSupportedDesc = prometheus.NewDesc(prefix+"laser_rx_power_supports_thresholds_bool", "1 if thresholds for the laser rx power are supported", laserLabels, nil)
-	if powerUnitdBm {
-		laserTxPowerDescDbm = prometheus.NewDesc(prefix+"laser_tx_power_dbm", "Laser tx power in dBm", laserLabels, nil)
-		laserTxPowerHighAlarmThresholdDescDbm = prometheus.NewDesc(prefix+"laser_tx_power_high_alarm_threshold_dbm", "High alarm threshold for the laser tx power in dBm", laserLabels, nil)
-		laserTxPowerHighWarningThresholdDescDbm = prometheus.NewDesc(prefix+"laser_tx_power_high_warning_threshold_dbm", "High warning threshold for the laser tx power in dBm", laserLabels, nil)
-		laserTxPowerLowAlarmThresholdDescDbm = prometheus.NewDesc(prefix+"laser_tx_power_low_alarm_threshold_dbm", "Low alarm threshold for the laser tx power in dBm", laserLabels, nil)
-		laserTxPowerLowWarningThresholdDescDbm = prometheus.NewDesc(prefix+"laser_tx_power_low_warning_threshold_dbm", "Low warning threshold for the laser tx power in dBm", laserLabels, nil)
-
-		laserRxPowerDescDbm = prometheus.NewDesc(prefix+"laser_rx_power_dbm", "Laser rx power in dBm", laserLabels, nil)
-		laserRxPowerHighAlarmThresholdDescDbm = prometheus.NewDesc(prefix+"laser_rx_power_high_alarm_threshold_dbm", "High alarm threshold for the laser rx power in dBm", laserLabels, nil)
-		laserRxPowerHighWarningThresholdDescDbm = prometheus.NewDesc(prefix+"laser_rx_power_high_warning_threshold_dbm", "High warning threshold for the laser rx power in dBm", laserLabels, nil)
-		laserRxPowerLowAlarmThresholdDescDbm = prometheus.NewDesc(prefix+"laser_rx_power_low_alarm_threshold_dbm", "Low alarm threshold for the laser rx power in dBm", laserLabels, nil)
-		laserRxPowerLowWarningThresholdDescDbm = prometheus.NewDesc(prefix+"laser_rx_power_low_warning_threshold_dbm", "Low warning threshold for the laser rx power in dBm", laserLabels, nil)
-	} else {
-		laserTxPowerDescMw = prometheus.NewDesc(prefix+"laser_tx_power_milliwatts", "Laser tx power in milliwatts", laserLabels, nil)
-		laserTxPowerHighAlarmThresholdDescMw = prometheus.NewDesc(prefix+"laser_tx_power_high_alarm_threshold_milliwatts", "High alarm threshold for the laser tx power in milliwatts", laserLabels, nil)
-		laserTxPowerHighWarningThresholdDescMw = prometheus.NewDesc(prefix+"laser_tx_power_high_warning_threshold_milliwatts", "High warning threshold for the laser tx power in milliwatts", laserLabels, nil)
-		laserTxPowerLowAlarmThresholdDescMw = prometheus.NewDesc(prefix+"laser_tx_power_low_alarm_threshold_milliwatts", "Low alarm threshold for the laser tx power in milliwatts", laserLabels, nil)
-		laserTxPowerLowWarningThresholdDescMw = prometheus.NewDesc(prefix+"laser_tx_power_low_warning_threshold_milliwatts", "Low warning threshold for the laser tx power in milliwatts", laserLabels, nil)
-
-		laserRxPowerDescMw = prometheus.NewDesc(prefix+"laser_rx_power_milliwatts", "Laser rx power in milliwatts", laserLabels, nil)
-		laserRxPowerHighAlarmThresholdDescMw = prometheus.NewDesc(prefix+"laser_rx_power_high_alarm_threshold_milliwatts", "High alarm threshold for the laser rx power in milliwatts", laserLabels, nil)
-		laserRxPowerHighWarningThresholdDescMw = prometheus.NewDesc(prefix+"laser_rx_power_high_warning_threshold_milliwatts", "High warning threshold for the laser rx power in milliwatts", laserLabels, nil)
-		laserRxPowerLowAlarmThresholdDescMw = prometheus.NewDesc(prefix+"laser_rx_power_low_alarm_threshold_milliwatts", "Low alarm threshold for the laser rx power in milliwatts", laserLabels, nil)
-		laserRxPowerLowWarningThresholdDescMw = prometheus.NewDesc(prefix+"laser_rx_power_low_warning_threshold_milliwatts", "Low warning threshold for the laser rx power in milliwatts", laserLabels, nil)
+func NewCollector(config Config) *TransceiverCollector {
+	scrapeStatus := config.ScrapeStatus
+	if scrapeStatus == nil {
+		scrapeStatus = NewScrapeStatus()
 	}
 
 	return &TransceiverCollector{
-		excludeInterfaces:        excludeInterfaces,
-		includeInterfaces:        includeInterfaces,
-		excludeInterfacesDown:    excludeInterfacesDown,
-		collectInterfaceFeatures: collectInterfaceFeatures,
-		powerUnitdBm:             powerUnitdBm,
+		excludeInterfaces:        config.ExcludeInterfaces,
+		includeInterfaces:        config.IncludeInterfaces,
+		excludeInterfacesDown:    config.ExcludeInterfacesDown,
+		collectInterfaceFeatures: config.CollectInterfaceFeatures,
+		featureFilter: featureFilter{
+			include:  config.InterfaceFeaturesInclude,
+			exclude:  config.InterfaceFeaturesExclude,
+			baseline: config.InterfaceFeaturesBaseline,
+		},
+		collectLinkModes:    config.CollectLinkModes,
+		driverStatsPattern:  config.DriverStatsPattern,
+		powerUnitdBm:        config.PowerUnitdBm,
+		legacyInfoMetrics:   config.LegacyInfoMetrics,
+		breakoutDetection:   config.BreakoutDetection,
+		portMap:             config.PortMap,
+		topology:            config.Topology,
+		remotePowerCache:    config.RemotePowerCache,
+		moduleAgeTracker:    config.ModuleAgeTracker,
+		carrierTracker:      config.CarrierTracker,
+		moduleChangeTracker: config.ModuleChangeTracker,
+		scrapeStatus:        scrapeStatus,
+		translator:          newMetricTranslator(config.Namespace, config.MetricSchema, config.LegacyMetricNames, config.InterfaceLabels, len(config.NetworkNamespaces) > 0),
+		networkNamespaces:   config.NetworkNamespaces,
+		collectors:          config.Collectors,
+		interfacePattern:    config.InterfacePattern,
 	}
 }
 
@@ -219,24 +322,85 @@ func (t *TransceiverCollector) Name() string {
 
 // Describe implements prometheus.Collector interface's Describe function
 func (t *TransceiverCollector) Describe(ch chan<- *prometheus.Desc) {
+	descs := make(chan *prometheus.Desc)
+	finished := make(chan struct{})
+	go func() {
+		t.translator.describe(descs, ch)
+		close(finished)
+	}()
+	t.describe(descs)
+	close(descs)
+	<-finished
+}
+
+func (t *TransceiverCollector) describe(ch chan<- *prometheus.Desc) {
 	ch <- driverDesc
 	ch <- driverVersionDesc
 	ch <- firmwareVersionDesc
 	ch <- busInfoDesc
 	ch <- expansionRomVersionDesc
+	if t.collectInterfaceFeatures {
+		ch <- interfaceFeatureAvailableDesc
+		ch <- interfaceFeatureActiveDesc
+	}
+	ch <- interfaceInfoDesc
+	ch <- interfaceOperStateDesc
+	ch <- interfaceMTUDesc
+	ch <- carrierChangesDesc
+	ch <- carrierUpChangesDesc
+	ch <- carrierDownChangesDesc
+	if t.carrierTracker != nil {
+		ch <- carrierLastChangeDesc
+		if t.powerUnitdBm {
+			ch <- carrierLastChangeRxPowerDescDbm
+		} else {
+			ch <- carrierLastChangeRxPowerDescMw
+		}
+	}
+	ch <- linkInfoDesc
+	ch <- linkSpeedDesc
+	ch <- linkAutonegotiationDesc
+	if t.collectLinkModes {
+		ch <- linkModeSupportedDesc
+		ch <- linkModeAdvertisedDesc
+		ch <- linkModePartnerAdvertisedDesc
+	}
+	ch <- fecInfoDesc
+	ch <- fecCorrectedCodewordsDesc
+	ch <- fecUncorrectableCodewordsDesc
+	ch <- fecCorrectedBitsDesc
+	ch <- fecLaneCorrectedCodewordsDesc
+	ch <- fecLaneUncorrectableCodewordsDesc
+	ch <- fecLaneCorrectedBitsDesc
+	if t.driverStatsPattern != nil {
+		ch <- driverStatsDesc
+	}
 
-	ch <- identifierDesc
-	ch <- encodingDesc
+	ch <- presentDesc
+	ch <- moduleStateDesc
+	if t.moduleChangeTracker != nil {
+		ch <- moduleInsertionsDesc
+		ch <- moduleRemovalsDesc
+		ch <- moduleSwapsDesc
+		ch <- moduleLastChangeDesc
+	}
+	ch <- moduleInfoDesc
+	if t.legacyInfoMetrics {
+		ch <- identifierDesc
+		ch <- encodingDesc
+		ch <- vendorNameDesc
+		ch <- vendorPNDesc
+		ch <- vendorRevDesc
+		ch <- vendorSNDesc
+		ch <- vendorOUIDesc
+	}
 	ch <- powerClassDesc
 	ch <- powerClassWattageDesc
 	ch <- signalingRateDesc
 	ch <- supportedLinkLengthsDesc
-	ch <- vendorNameDesc
-	ch <- vendorPNDesc
-	ch <- vendorRevDesc
-	ch <- vendorSNDesc
-	ch <- vendorOUIDesc
 	ch <- dateCodeDesc
+	ch <- moduleManufactureAgeDesc
+	ch <- moduleInServiceDesc
 	ch <- wavelengthDesc
 	ch <- moduleSupportsMonitoringDesc
 	ch <- moduleTemperatureDesc
@@ -252,6 +416,7 @@ func (t *TransceiverCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- moduleVoltageLowAlarmThresholdDesc
 	ch <- moduleVoltageLowWarningThresholdDesc
 
+	ch <- laserWavelengthDesc
 	ch <- laserSupportsMonitoringDesc
 
 	ch <- laserBiasDesc
@@ -289,16 +454,39 @@ func (t *TransceiverCollector) Describe(ch chan<- *prometheus.Desc) {
 		ch <- laserRxPowerLowAlarmThresholdDescMw
 		ch <- laserRxPowerLowWarningThresholdDescMw
 	}
+
+	ch <- laserTxPowerMarginDesc
+	ch <- laserRxPowerMarginDesc
+	ch <- moduleTxPowerWorstMarginDesc
+	ch <- moduleRxPowerWorstMarginDesc
+	ch <- spanLossDesc
+
+	t.scrapeStatus.describe(ch)
 }
 
 func (t *TransceiverCollector) getMonitoredInterfaces() ([]string, error) {
+	ifaceNames, err := listInterfaces(t.excludeInterfaces, t.includeInterfaces, t.excludeInterfacesDown)
+	if err != nil || t.interfacePattern == nil {
+		return ifaceNames, err
+	}
+	matching := []string{}
+	for _, ifaceName := range ifaceNames {
+		if t.interfacePattern.MatchString(ifaceName) {
+			matching = append(matching, ifaceName)
+		}
+	}
+	return matching, nil
+}
+
+// listInterfaces returns the names of all interfaces except loopbacks, filtered by the given include or exclude list
+func listInterfaces(excludeInterfaces []string, includeInterfaces []string, excludeInterfacesDown bool) ([]string, error) {
 	interfaces, err := net.Interfaces()
 	if err != nil {
 		return []string{}, errors.Wrapf(err, "Could not enumerate system's interfaces")
 	}
 
-	InterfacesExcluded := len(t.excludeInterfaces) > 0
-	InterfacesIncluded := len(t.includeInterfaces) > 0
+	InterfacesExcluded := len(excludeInterfaces) > 0
+	InterfacesIncluded := len(includeInterfaces) > 0
 	if InterfacesExcluded && InterfacesIncluded {
 		return []string{}, errors.New("Cannot include and exclude interfaces at the same time")
 	}
@@ -308,13 +496,13 @@ func (t *TransceiverCollector) getMonitoredInterfaces() ([]string, error) {
 		if iface.Flags&net.FlagLoopback > 0 {
 			continue
 		}
-		if iface.Flags&net.FlagUp == 0 && t.excludeInterfacesDown {
+		if iface.Flags&net.FlagUp == 0 && excludeInterfacesDown {
 			continue
 		}
-		if InterfacesExcluded && contains(t.excludeInterfaces, iface.Name) {
+		if InterfacesExcluded && contains(excludeInterfaces, iface.Name) {
 			continue
 		}
-		if InterfacesIncluded && !contains(t.includeInterfaces, iface.Name) {
+		if InterfacesIncluded && !contains(includeInterfaces, iface.Name) {
 			continue
 		}
 
@@ -325,151 +513,319 @@ func (t *TransceiverCollector) getMonitoredInterfaces() ([]string, error) {
 
 // Collect implements prometheus.Collector interface's Collect function
 func (t *TransceiverCollector) Collect(ch chan<- prometheus.Metric, errs chan error, done chan struct{}) {
-	defer func() {
-		done <- struct{}{}
+	collector := *t
+	// scrapes of selected interfaces must not replace the snapshot of all of them
+	if t.interfacePattern == nil {
+		collector.snapshot = newTransceiverSnapshot()
+	}
+	namespaces, err := listNetworkNamespaces(t.networkNamespaces)
+	if err != nil {
+		t.scrapeStatus.recordError("", "", ErrorReasonNetns)
+		collector.snapshot.addError(AllNetworkNamespaces, err)
+		errs <- err
+	}
+	for _, netns := range namespaces {
+		collector.collectNetworkNamespace(netns, ch, errs)
+	}
+	if collector.snapshot != nil {
+		t.scrapeStatus.recordTransceivers(collector.snapshot)
+	}
+	done <- struct{}{}
+}
+
+// collectNetworkNamespace collects the interfaces of a network namespace, labelling the metrics with its name
+func (t *TransceiverCollector) collectNetworkNamespace(netns string, ch chan<- prometheus.Metric, errs chan error) {
+	metrics := make(chan prometheus.Metric)
+	finished := make(chan struct{})
+	go func() {
+		t.translator.collect(netns, metrics, ch, errs)
+		close(finished)
 	}()
+	collector := *t
+	collector.netns = netns
+	err := inNetworkNamespace(netns, func() {
+		collector.collect(metrics, errs)
+	})
+	if err != nil {
+		t.scrapeStatus.recordError(netns, "", ErrorReasonNetns)
+		t.snapshot.addError(netns, err)
+		errs <- err
+	}
+	t.scrapeStatus.export(netns, metrics)
+	close(metrics)
+	<-finished
+}
 
+func (t *TransceiverCollector) collect(ch chan<- prometheus.Metric, errs chan error) {
 	ifaceNames, err := t.getMonitoredInterfaces()
 	if err != nil {
-		log.Error(err.Error())
+		t.scrapeStatus.recordError(t.netns, "", ErrorReasonEnumerate)
+		t.snapshot.addError(t.netns, err)
+		errs <- err
 		return
 	}
 	tool, err := ethtool.NewEthtool()
 	if err != nil {
+		t.scrapeStatus.recordError(t.netns, "", ErrorReasonEthtool)
+		t.snapshot.addError(t.netns, errors.Wrapf(err, "Could not instanciate ethtool"))
 		errs <- fmt.Errorf("Could not instanciate ethtool: %v", err)
 		return
 	}
 	defer tool.Close()
+	socket, err := newEthtoolSocket()
+	if err != nil {
+		t.scrapeStatus.recordError(t.netns, "", ErrorReasonEthtool)
+		errs <- fmt.Errorf("Could not open ethtool socket: %v", err)
+		return
+	}
+	defer socket.close()
 
-	for _, ifaceName := range ifaceNames {
-		iface, err := tool.NewInterface(ifaceName, true)
-		if err != nil {
-			errs <- fmt.Errorf("Error fetching information for interface %s: %v", ifaceName, err)
-			continue
+	for _, port := range t.groupInterfacesByPort(ifaceNames) {
+		t.collectPort(tool, socket, port, ch, errs)
+	}
+}
+
+// collectPort reads the transceiver of a physical port once and exports its lanes for the netdevs using them
+func (t *TransceiverCollector) collectPort(tool *ethtool.Ethtool, socket *ethtoolSocket, port *physicalPort, ch chan<- prometheus.Metric, errs chan error) {
+	start := time.Now()
+	primary, err := tool.NewInterface(port.members[0].ifaceName, false)
+	// go-ethtool returns the handle along with the error if only reading the EEPROM failed
+	var eepromErr error
+	if err != nil && primary != nil && primary.DriverInfo != nil {
+		eepromErr, err = err, nil
+	}
+	if t.snapshot != nil {
+		t.snapshot.addTransceivers(t.portTransceivers(port, primary, err, eepromErr, start))
+	}
+	if err == nil && primary == nil {
+		err = fmt.Errorf("No interface handle returned")
+	}
+	if err != nil {
+		for _, member := range port.members {
+			t.exportScrapeResult(member.ifaceName, start, false, ch)
 		}
-		if iface != nil {
-			t.exportMetricsForInterface(iface, ch)
+		t.scrapeStatus.recordError(t.netns, port.members[0].ifaceName, ErrorReasonInterface)
+		errs <- fmt.Errorf("Error fetching information for interface %s: %v", port.members[0].ifaceName, err)
+		return
+	}
+	moduleState := getModuleState(primary.Eeprom, eepromErr)
+	if moduleState == moduleStateError {
+		t.scrapeStatus.recordError(t.netns, port.members[0].ifaceName, ErrorReasonEEPROM)
+		errs <- fmt.Errorf("Error reading EEPROM of interface %s: %v", port.members[0].ifaceName, eepromErr)
+	}
+
+	for index, member := range port.members {
+		if index > 0 {
+			start = time.Now()
+		}
+		readErrors := t.scrapeStatus.readErrorCount(t.netns, member.ifaceName)
+		// the members share the primary's handle, features are netdev specific and thus read using the socket
+		t.exportMetricsForInterface(member.ifaceName, primary, socket, ch)
+		if t.collectors.has(CollectInterface) {
+			exportInterfaceInfo(member.ifaceName, socket, ch)
+			t.exportCarrier(member.ifaceName, ch)
+		}
+		if t.collectors.has(CollectLink) {
+			t.exportLinkSettings(member.ifaceName, primary, socket, ch)
+		}
+		if t.collectors.has(CollectFEC) {
+			t.exportFEC(member.ifaceName, socket, ch)
 		}
+		if t.collectors.has(CollectDriverStats) {
+			t.exportDriverStats(member.ifaceName, socket, ch)
+		}
+		if t.collectors.has(CollectInventory) {
+			exportModuleState(member.ifaceName, moduleState, ch)
+		}
+		t.exportModuleChanges(member.ifaceName, moduleState, primary.Eeprom, ch)
+		if primary.Eeprom != nil {
+			localPowers := t.exportEEPROMMetricsForInterface(member.ifaceName, port.name, port.lanesFor(index, len(primary.Eeprom.GetLasers())), primary.Eeprom, ch)
+			if t.collectors.has(CollectDOM) {
+				t.exportSpanLoss(member.ifaceName, localPowers, ch, errs)
+			}
+			if t.collectors.has(CollectInventory) {
+				t.exportModuleAge(member.ifaceName, primary.Eeprom, ch, errs)
+			}
+		}
+		// the port only counts as read successfully if neither its module nor any of the netdev's attributes failed
+		success := moduleState != moduleStateError && t.scrapeStatus.readErrorCount(t.netns, member.ifaceName) == readErrors
+		t.exportScrapeResult(member.ifaceName, start, success, ch)
 	}
 }
 
-func (t *TransceiverCollector) exportMetricsForInterface(iface *ethtool.Interface, ch chan<- prometheus.Metric) {
-	if t.collectInterfaceFeatures {
-		features, err := iface.GetFeatures()
+func (t *TransceiverCollector) exportScrapeResult(ifaceName string, start time.Time, success bool, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds(), ifaceName)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, boolToFloat64(success), ifaceName)
+	if success {
+		t.scrapeStatus.recordSuccess(t.netns, ifaceName, time.Now())
+	}
+}
+
+func (t *TransceiverCollector) exportMetricsForInterface(ifaceName string, iface *ethtool.Interface, socket *ethtoolSocket, ch chan<- prometheus.Metric) {
+	if t.collectInterfaceFeatures && t.collectors.has(CollectFeatures) {
+		features, err := socket.getFeatures(ifaceName)
 		if err == nil {
 			for name, status := range features {
-				ch <- prometheus.MustNewConstMetric(interfaceFeatureAvailableDesc, prometheus.GaugeValue, boolToFloat64(status.Available), iface.Name, name)
-				ch <- prometheus.MustNewConstMetric(interfaceFeatureActiveDesc, prometheus.GaugeValue, boolToFloat64(status.Active), iface.Name, name)
+				if !t.featureFilter.matches(name, status) {
+					continue
+				}
+				ch <- prometheus.MustNewConstMetric(interfaceFeatureAvailableDesc, prometheus.GaugeValue, boolToFloat64(status.Available), ifaceName, name)
+				ch <- prometheus.MustNewConstMetric(interfaceFeatureActiveDesc, prometheus.GaugeValue, boolToFloat64(status.Active), ifaceName, name)
 			}
+		} else {
+			t.scrapeStatus.recordError(t.netns, ifaceName, ErrorReasonFeatures)
 		}
 	}
-	if iface.DriverInfo != nil {
-		exportDriverInfoMetricsForInterface(iface.Name, iface.DriverInfo, ch)
-	}
-	if iface.Eeprom != nil {
-		t.exportEEPROMMetricsForInterface(iface.Name, iface.Eeprom, ch)
+	if iface.DriverInfo != nil && t.collectors.has(CollectDriver) {
+		exportDriverInfoMetricsForInterface(ifaceName, iface.DriverInfo, ch)
 	}
 }
 
-func exportDriverInfoMetricsForInterface(ifaceName string, driverInfo *ethtool.DriverInfo, ch chan<- prometheus.Metric) {
-	ch <- prometheus.MustNewConstMetric(driverDesc, prometheus.GaugeValue, 1, ifaceName, driverInfo.DriverName)
-	ch <- prometheus.MustNewConstMetric(driverVersionDesc, prometheus.GaugeValue, 1, ifaceName, driverInfo.DriverVersion)
+func exportDriverInfoMetricsForInterface(ifaceName string, ethtoolDriverInfo *ethtool.DriverInfo, ch chan<- prometheus.Metric) {
+	driverInfo := newDriverInfo(ethtoolDriverInfo)
+	ch <- prometheus.MustNewConstMetric(driverDesc, prometheus.GaugeValue, 1, ifaceName, driverInfo.Name)
+	ch <- prometheus.MustNewConstMetric(driverVersionDesc, prometheus.GaugeValue, 1, ifaceName, driverInfo.Version)
 	ch <- prometheus.MustNewConstMetric(firmwareVersionDesc, prometheus.GaugeValue, 1, ifaceName, driverInfo.FirmwareVersion)
 	ch <- prometheus.MustNewConstMetric(busInfoDesc, prometheus.GaugeValue, 1, ifaceName, driverInfo.BusInfo)
 	ch <- prometheus.MustNewConstMetric(expansionRomVersionDesc, prometheus.GaugeValue, 1, ifaceName, driverInfo.ExpansionRomVersion)
 }
 
-func (t *TransceiverCollector) exportEEPROMMetricsForInterface(ifaceName string, rom eeprom.EEPROM, ch chan<- prometheus.Metric) {
-	ch <- prometheus.MustNewConstMetric(identifierDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetIdentifier().String())
-	ch <- prometheus.MustNewConstMetric(encodingDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetEncoding())
+// exportEEPROMMetricsForInterface exports the module's metrics for a netdev, restricted to the given lanes (nil meaning all lanes).
+// It returns the optical powers of the exported lanes.
+func (t *TransceiverCollector) exportEEPROMMetricsForInterface(ifaceName string, portName string, lanes []int, rom eeprom.EEPROM, ch chan<- prometheus.Metric) map[int]*lanePower {
+	if t.collectors.has(CollectInventory) {
+		t.exportInventoryMetricsForInterface(ifaceName, portName, lanes, rom, ch)
+	}
+	powers := make(map[int]*lanePower)
+	if !t.collectors.has(CollectDOM) || !rom.SupportsMonitoring() {
+		return powers
+	}
+
+	temperature, err := rom.GetModuleTemperature()
+	if err == nil {
+		exportMeasurement([]string{ifaceName}, temperature, &measurementDesc{
+			moduleTemperatureDesc,
+			moduleTemperatureThresholdsSupportedDesc,
+			moduleTemperatureHighAlarmThresholdDesc,
+			moduleTemperatureHighWarningThresholdDesc,
+			moduleTemperatureLowAlarmThresholdDesc,
+			moduleTemperatureLowWarningThresholdDesc,
+		}, ch)
+	}
+	voltage, err := rom.GetModuleVoltage()
+	if err == nil {
+		exportMeasurement([]string{ifaceName}, voltage, &measurementDesc{
+			moduleVoltageDesc,
+			moduleVoltageThresholdsSupportedDesc,
+			moduleVoltageHighAlarmThresholdDesc,
+			moduleVoltageHighWarningThresholdDesc,
+			moduleVoltageLowAlarmThresholdDesc,
+			moduleVoltageLowWarningThresholdDesc,
+		}, ch)
+	}
+	txWorstMargins := make(powerMargins)
+	rxWorstMargins := make(powerMargins)
+	for index, laser := range rom.GetLasers() {
+		if !laser.SupportsMonitoring() {
+			continue
+		}
+		if lanes != nil && !containsInt(lanes, index) {
+			continue
+		}
+		laserLabels := []string{ifaceName, portName, strconv.Itoa(index)}
+		power := &lanePower{}
+		powers[index] = power
+
+		bias, err := laser.GetBias()
+		if err == nil {
+			exportMeasurement(laserLabels, bias, &measurementDesc{
+				laserBiasDesc,
+				laserBiasThresholdsSupportedDesc,
+				laserBiasHighAlarmThresholdDesc,
+				laserBiasHighWarningThresholdDesc,
+				laserBiasLowAlarmThresholdDesc,
+				laserBiasLowWarningThresholdDesc,
+			}, ch)
+		}
+		txPower, err := laser.GetTxPower()
+		if err == nil {
+			margins := t.exportMeasurementLightLevels(laserLabels, txPower, &measurementDescLightLevels{
+				ThresholdsSupportedDesc:      laserTxPowerThresholdsSupportedDesc,
+				MarginDesc:                   laserTxPowerMarginDesc,
+				ValueDescMw:                  laserTxPowerDescMw,
+				ThresholdsHighAlarmDescMw:    laserTxPowerHighAlarmThresholdDescMw,
+				ThresholdsHighWarningDescMw:  laserTxPowerHighWarningThresholdDescMw,
+				ThresholdsLowAlarmDescMw:     laserTxPowerLowAlarmThresholdDescMw,
+				ThresholdsLowWarningDescMw:   laserTxPowerLowWarningThresholdDescMw,
+				ValueDescDbm:                 laserTxPowerDescDbm,
+				ThresholdsHighAlarmDescDbm:   laserTxPowerHighAlarmThresholdDescDbm,
+				ThresholdsHighWarningDescDbm: laserTxPowerHighWarningThresholdDescDbm,
+				ThresholdsLowAlarmDescDbm:    laserTxPowerLowAlarmThresholdDescDbm,
+				ThresholdsLowWarningDescDbm:  laserTxPowerLowWarningThresholdDescDbm,
+			}, ch)
+			txWorstMargins.mergeWorst(margins)
+			power.setTxMilliwatts(txPower.GetValue())
+		}
+		rxPower, err := laser.GetRxPower()
+		if err == nil {
+			margins := t.exportMeasurementLightLevels(laserLabels, rxPower, &measurementDescLightLevels{
+				ThresholdsSupportedDesc:      laserRxPowerThresholdsSupportedDesc,
+				MarginDesc:                   laserRxPowerMarginDesc,
+				ValueDescMw:                  laserRxPowerDescMw,
+				ThresholdsHighAlarmDescMw:    laserRxPowerHighAlarmThresholdDescMw,
+				ThresholdsHighWarningDescMw:  laserRxPowerHighWarningThresholdDescMw,
+				ThresholdsLowAlarmDescMw:     laserRxPowerLowAlarmThresholdDescMw,
+				ThresholdsLowWarningDescMw:   laserRxPowerLowWarningThresholdDescMw,
+				ValueDescDbm:                 laserRxPowerDescDbm,
+				ThresholdsHighAlarmDescDbm:   laserRxPowerHighAlarmThresholdDescDbm,
+				ThresholdsHighWarningDescDbm: laserRxPowerHighWarningThresholdDescDbm,
+				ThresholdsLowAlarmDescDbm:    laserRxPowerLowAlarmThresholdDescDbm,
+				ThresholdsLowWarningDescDbm:  laserRxPowerLowWarningThresholdDescDbm,
+			}, ch)
+			rxWorstMargins.mergeWorst(margins)
+			power.setRxMilliwatts(rxPower.GetValue())
+		}
+	}
+	txWorstMargins.export(moduleTxPowerWorstMarginDesc, []string{ifaceName}, ch)
+	rxWorstMargins.export(moduleRxPowerWorstMarginDesc, []string{ifaceName}, ch)
+	return powers
+}
+
+// exportInventoryMetricsForInterface exports the identity and static properties of the module for a netdev, restricted to the given lanes (nil meaning all lanes)
+func (t *TransceiverCollector) exportInventoryMetricsForInterface(ifaceName string, portName string, lanes []int, rom eeprom.EEPROM, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(moduleInfoDesc, prometheus.GaugeValue, 1, ifaceName,
+		rom.GetVendorName(),
+		rom.GetVendorPN(),
+		rom.GetVendorRev(),
+		rom.GetVendorSN(),
+		rom.GetVendorOUI().String(),
+		rom.GetIdentifier().String(),
+		rom.GetEncoding(),
+		rom.GetConnectorType().String())
+	if t.legacyInfoMetrics {
+		ch <- prometheus.MustNewConstMetric(identifierDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetIdentifier().String())
+		ch <- prometheus.MustNewConstMetric(encodingDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetEncoding())
+		ch <- prometheus.MustNewConstMetric(vendorNameDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorName())
+		ch <- prometheus.MustNewConstMetric(vendorPNDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorPN())
+		ch <- prometheus.MustNewConstMetric(vendorRevDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorRev())
+		ch <- prometheus.MustNewConstMetric(vendorSNDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorSN())
+		ch <- prometheus.MustNewConstMetric(vendorOUIDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorOUI().String())
+	}
 	ch <- prometheus.MustNewConstMetric(powerClassDesc, prometheus.GaugeValue, float64(byte(rom.GetPowerClass())), ifaceName)
 	ch <- prometheus.MustNewConstMetric(powerClassWattageDesc, prometheus.GaugeValue, rom.GetPowerClass().GetMaxPower(), ifaceName)
 	ch <- prometheus.MustNewConstMetric(signalingRateDesc, prometheus.GaugeValue, rom.GetSignalingRate(), ifaceName)
 	for mediaName, supportedLength := range rom.GetSupportedLinkLengths() {
 		ch <- prometheus.MustNewConstMetric(supportedLinkLengthsDesc, prometheus.GaugeValue, supportedLength, ifaceName, mediaName)
 	}
-	ch <- prometheus.MustNewConstMetric(vendorNameDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorName())
-	ch <- prometheus.MustNewConstMetric(vendorPNDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorPN())
-	ch <- prometheus.MustNewConstMetric(vendorRevDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorRev())
-	ch <- prometheus.MustNewConstMetric(vendorSNDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorSN())
-	ch <- prometheus.MustNewConstMetric(vendorOUIDesc, prometheus.GaugeValue, 1, ifaceName, rom.GetVendorOUI().String())
 	ch <- prometheus.MustNewConstMetric(dateCodeDesc, prometheus.GaugeValue, float64(rom.GetDateCode().Unix()), ifaceName)
 	ch <- prometheus.MustNewConstMetric(wavelengthDesc, prometheus.GaugeValue, rom.GetWavelength(), ifaceName)
 	ch <- prometheus.MustNewConstMetric(moduleSupportsMonitoringDesc, prometheus.GaugeValue, boolToFloat64(rom.SupportsMonitoring()), ifaceName)
 
-	if rom.SupportsMonitoring() {
-		temperature, err := rom.GetModuleTemperature()
-		if err == nil {
-			exportMeasurement([]string{ifaceName}, temperature, &measurementDesc{
-				moduleTemperatureDesc,
-				moduleTemperatureThresholdsSupportedDesc,
-				moduleTemperatureHighAlarmThresholdDesc,
-				moduleTemperatureHighWarningThresholdDesc,
-				moduleTemperatureLowAlarmThresholdDesc,
-				moduleTemperatureLowWarningThresholdDesc,
-			}, ch)
-		}
-		voltage, err := rom.GetModuleVoltage()
-		if err == nil {
-			exportMeasurement([]string{ifaceName}, voltage, &measurementDesc{
-				moduleVoltageDesc,
-				moduleVoltageThresholdsSupportedDesc,
-				moduleVoltageHighAlarmThresholdDesc,
-				moduleVoltageHighWarningThresholdDesc,
-				moduleVoltageLowAlarmThresholdDesc,
-				moduleVoltageLowWarningThresholdDesc,
-			}, ch)
-		}
-		for index, laser := range rom.GetLasers() {
-			if !laser.SupportsMonitoring() {
-				continue
-			}
-			laserLabels := []string{ifaceName, strconv.Itoa(index)}
-
-			bias, err := laser.GetBias()
-			if err == nil {
-				exportMeasurement(laserLabels, bias, &measurementDesc{
-					laserBiasDesc,
-					laserBiasThresholdsSupportedDesc,
-					laserBiasHighAlarmThresholdDesc,
-					laserBiasHighWarningThresholdDesc,
-					laserBiasLowAlarmThresholdDesc,
-					laserBiasLowWarningThresholdDesc,
-				}, ch)
-			}
-			txPower, err := laser.GetTxPower()
-			if err == nil {
-				t.exportMeasurementLightLevels(laserLabels, txPower, &measurementDescLightLevels{
-					ThresholdsSupportedDesc:      laserTxPowerThresholdsSupportedDesc,
-					ValueDescMw:                  laserTxPowerDescMw,
-					ThresholdsHighAlarmDescMw:    laserTxPowerHighAlarmThresholdDescMw,
-					ThresholdsHighWarningDescMw:  laserTxPowerHighWarningThresholdDescMw,
-					ThresholdsLowAlarmDescMw:     laserTxPowerLowAlarmThresholdDescMw,
-					ThresholdsLowWarningDescMw:   laserTxPowerLowWarningThresholdDescMw,
-					ValueDescDbm:                 laserTxPowerDescDbm,
-					ThresholdsHighAlarmDescDbm:   laserTxPowerHighAlarmThresholdDescDbm,
-					ThresholdsHighWarningDescDbm: laserTxPowerHighWarningThresholdDescDbm,
-					ThresholdsLowAlarmDescDbm:    laserTxPowerLowAlarmThresholdDescDbm,
-					ThresholdsLowWarningDescDbm:  laserTxPowerLowWarningThresholdDescDbm,
-				}, ch)
-			}
-			rxPower, err := laser.GetRxPower()
-			if err == nil {
-				t.exportMeasurementLightLevels(laserLabels, rxPower, &measurementDescLightLevels{
-					ThresholdsSupportedDesc:      laserRxPowerThresholdsSupportedDesc,
-					ValueDescMw:                  laserRxPowerDescMw,
-					ThresholdsHighAlarmDescMw:    laserRxPowerHighAlarmThresholdDescMw,
-					ThresholdsHighWarningDescMw:  laserRxPowerHighWarningThresholdDescMw,
-					ThresholdsLowAlarmDescMw:     laserRxPowerLowAlarmThresholdDescMw,
-					ThresholdsLowWarningDescMw:   laserRxPowerLowWarningThresholdDescMw,
-					ValueDescDbm:                 laserRxPowerDescDbm,
-					ThresholdsHighAlarmDescDbm:   laserRxPowerHighAlarmThresholdDescDbm,
-					ThresholdsHighWarningDescDbm: laserRxPowerHighWarningThresholdDescDbm,
-					ThresholdsLowAlarmDescDbm:    laserRxPowerLowAlarmThresholdDescDbm,
-					ThresholdsLowWarningDescDbm:  laserRxPowerLowWarningThresholdDescDbm,
-				}, ch)
-			}
+	for index, wavelength := range getLaneWavelengths(rom) {
+		if wavelength <= 0 || (lanes != nil && !containsInt(lanes, index)) {
+			continue
 		}
+		ch <- prometheus.MustNewConstMetric(laserWavelengthDesc, prometheus.GaugeValue, wavelength, ifaceName, portName, strconv.Itoa(index))
 	}
 }
 
@@ -489,7 +845,8 @@ func exportMeasurement(labels []string, measurement eeprom.Measurement, measurem
 	}
 }
 
-func (t *TransceiverCollector) exportMeasurementLightLevels(labels []string, measurement eeprom.Measurement, measurementDesc *measurementDescLightLevels, ch chan<- prometheus.Metric) {
+// exportMeasurementLightLevels exports an optical power reading and returns its margins to the thresholds (nil if unsupported)
+func (t *TransceiverCollector) exportMeasurementLightLevels(labels []string, measurement eeprom.Measurement, measurementDesc *measurementDescLightLevels, ch chan<- prometheus.Metric) powerMargins {
 	if t.powerUnitdBm {
 		ch <- prometheus.MustNewConstMetric(measurementDesc.ValueDescDbm, prometheus.GaugeValue, milliwattsToDbm(measurement.GetValue()), labels...)
 	} else {
@@ -501,7 +858,7 @@ func (t *TransceiverCollector) exportMeasurementLightLevels(labels []string, mea
 	if thresholdsSupported {
 		thresholds, err := measurement.GetAlarmThresholds()
 		if err != nil {
-			return
+			return nil
 		}
 
 		if t.powerUnitdBm {
@@ -515,5 +872,10 @@ func (t *TransceiverCollector) exportMeasurementLightLevels(labels []string, mea
 			ch <- prometheus.MustNewConstMetric(measurementDesc.ThresholdsLowAlarmDescMw, prometheus.GaugeValue, thresholds.GetLowAlarm(), labels...)
 			ch <- prometheus.MustNewConstMetric(measurementDesc.ThresholdsLowWarningDescMw, prometheus.GaugeValue, thresholds.GetLowWarning(), labels...)
 		}
+
+		margins := getPowerMargins(measurement.GetValue(), thresholds)
+		margins.export(measurementDesc.MarginDesc, labels, ch)
+		return margins
 	}
+	return nil
 }
@@ -0,0 +1,50 @@
+package transceivercollector
+
+import (
+	"fmt"
+)
+
+// Metric groups which can be selected per scrape (collect[] URL query parameter)
+const (
+	// CollectDOM module temperature and voltage, laser bias current and optical powers including thresholds, margins and span loss
+	CollectDOM = "dom"
+	// CollectInventory module identity, presence, insertions / removals / swaps, age and nominal wavelengths
+	CollectInventory = "inventory"
+	// CollectFeatures interface features
+	CollectFeatures = "features"
+	// CollectDriver driver and firmware versions and bus information
+	CollectDriver = "driver"
+	// CollectDriverStats driver statistics (ethtool -S)
+	CollectDriverStats = "driver_stats"
+	// CollectInterface interface information, MTU and carrier changes
+	CollectInterface = "interface"
+	// CollectLink link settings and link modes
+	CollectLink = "link"
+	// CollectFEC FEC modes and counters
+	CollectFEC = "fec"
+)
+
+// Collectors are the names of all metric groups
+var Collectors = []string{CollectDOM, CollectInventory, CollectFeatures, CollectDriver, CollectDriverStats, CollectInterface, CollectLink, CollectFEC}
+
+// CollectorSet is a set of metric groups to collect, nil meaning all groups
+type CollectorSet map[string]bool
+
+// ParseCollectorSet returns the set of the given metric group names, nil if names is empty
+func ParseCollectorSet(names []string) (CollectorSet, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	set := make(CollectorSet)
+	for _, name := range names {
+		if !contains(Collectors, name) {
+			return nil, fmt.Errorf("Unknown collector %q, expected one of %v", name, Collectors)
+		}
+		set[name] = true
+	}
+	return set, nil
+}
+
+func (c CollectorSet) has(name string) bool {
+	return c == nil || c[name]
+}
@@ -0,0 +1,102 @@
+package transceivercollector
+
+import (
+	"bytes"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ethtoolGetStringSetInfo = 0x00000037
+	ethtoolGetStrings       = 0x0000001b
+	ethtoolGetStats         = 0x0000001d
+
+	stringSetStats    = 1
+	ethtoolGStringLen = 32
+)
+
+var driverStatsDesc *prometheus.Desc
+
+func init() {
+	driverStatsDesc = newDesc("driver_stats_total", "Driver specific statistic of the interface as reported by ethtool -S", []string{"interface", "stat"})
+}
+
+// getStringSetLength returns the number of strings of a string set
+func (s *ethtoolSocket) getStringSetLength(ifaceName string, stringSet uint32) (int, error) {
+	// struct ethtool_sset_info followed by one u32 per requested set
+	request := make([]byte, 20)
+	nativeEndian.PutUint32(request[0:4], ethtoolGetStringSetInfo)
+	nativeEndian.PutUint64(request[8:16], 1<<stringSet)
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&request[0])); err != nil {
+		return 0, err
+	}
+	if nativeEndian.Uint64(request[8:16]) == 0 {
+		return 0, nil
+	}
+	return int(nativeEndian.Uint32(request[16:20])), nil
+}
+
+// getStrings returns a string set. Unlike go-ethtool the buffer is sized by the number of strings, as drivers may have thousands of statistics.
+func (s *ethtoolSocket) getStrings(ifaceName string, stringSet uint32, length int) ([]string, error) {
+	// struct ethtool_gstrings followed by the strings
+	request := make([]byte, 12+length*ethtoolGStringLen)
+	nativeEndian.PutUint32(request[0:4], ethtoolGetStrings)
+	nativeEndian.PutUint32(request[4:8], stringSet)
+	nativeEndian.PutUint32(request[8:12], uint32(length))
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&request[0])); err != nil {
+		return nil, err
+	}
+	strings := make([]string, length)
+	for index := range strings {
+		offset := 12 + index*ethtoolGStringLen
+		strings[index] = string(bytes.TrimRight(request[offset:offset+ethtoolGStringLen], "\x00"))
+	}
+	return strings, nil
+}
+
+// getDriverStats returns the driver statistics of an interface by name
+func (s *ethtoolSocket) getDriverStats(ifaceName string) (map[string]uint64, error) {
+	length, err := s.getStringSetLength(ifaceName, stringSetStats)
+	if err != nil || length == 0 {
+		return nil, err
+	}
+	names, err := s.getStrings(ifaceName, stringSetStats, length)
+	if err != nil {
+		return nil, err
+	}
+
+	// struct ethtool_stats followed by the values
+	request := make([]byte, 8+length*8)
+	nativeEndian.PutUint32(request[0:4], ethtoolGetStats)
+	nativeEndian.PutUint32(request[4:8], uint32(length))
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&request[0])); err != nil {
+		return nil, err
+	}
+	stats := make(map[string]uint64, length)
+	for index, name := range names {
+		stats[name] = nativeEndian.Uint64(request[8+index*8 : 16+index*8])
+	}
+	return stats, nil
+}
+
+// exportDriverStats exports the driver statistics of a netdev matching the configured pattern
+func (t *TransceiverCollector) exportDriverStats(ifaceName string, socket *ethtoolSocket, ch chan<- prometheus.Metric) {
+	if t.driverStatsPattern == nil {
+		return
+	}
+	stats, err := socket.getDriverStats(ifaceName)
+	if err == unix.EOPNOTSUPP {
+		return
+	}
+	if err != nil {
+		t.scrapeStatus.recordError(t.netns, ifaceName, ErrorReasonDriverStats)
+		return
+	}
+	for name, value := range stats {
+		if t.driverStatsPattern.MatchString(name) {
+			ch <- prometheus.MustNewConstMetric(driverStatsDesc, prometheus.CounterValue, float64(value), ifaceName, name)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package transceivercollector
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// Attributes of ETHTOOL_MSG_MODULE_EEPROM_GET requests and replies
+const (
+	ethtoolAttrModuleEEPROMHeader     = 1
+	ethtoolAttrModuleEEPROMOffset     = 2
+	ethtoolAttrModuleEEPROMLength     = 3
+	ethtoolAttrModuleEEPROMPage       = 4
+	ethtoolAttrModuleEEPROMBank       = 5
+	ethtoolAttrModuleEEPROMI2CAddress = 6
+	ethtoolAttrModuleEEPROMData       = 7
+)
+
+// Layout of paged module memory: the lower half is always accessible, the upper half maps the selected page
+const (
+	eepromHalfPageSize = 128
+	eepromPageSize     = 256
+)
+
+// DefaultI2CAddress is the I2C address of the module memory, SFF-8472 modules report diagnostics at 0x51
+const DefaultI2CAddress = 0x50
+
+// ErrInterfaceNotFound is returned for interfaces which do not exist or are not monitored
+var ErrInterfaceNotFound = errors.New("Interface does not exist or is not monitored")
+
+// EEPROMDumpRequest selects the module memory to dump
+type EEPROMDumpRequest struct {
+	Interface string
+	// Netns is the named network namespace of the interface, empty for the exporter's own
+	Netns string
+	// Paged reads the given page and bank at the given I2C address using ethtool netlink (Linux 5.13+).
+	// Offsets 0-127 address the lower memory, offsets 128-255 the selected page.
+	// Otherwise the memory is read as mapped by the driver (as `ethtool -m raw on` does).
+	Paged      bool
+	Page       uint8
+	Bank       uint8
+	I2CAddress uint8
+	Offset     uint32
+	// Length is the number of bytes to read, all bytes from the offset if 0
+	Length uint32
+}
+
+type ethtoolModInfo struct {
+	cmd       uint32
+	modType   uint32
+	eepromLen uint32
+	reserved  [8]uint32
+}
+
+// ethtoolEEPROMHeader is the header of struct ethtool_eeprom, which is followed by the data
+type ethtoolEEPROMHeader struct {
+	cmd    uint32
+	magic  uint32
+	offset uint32
+	length uint32
+}
+
+// getModuleEEPROMLength returns the size of the module memory as mapped by the driver
+func (s *ethtoolSocket) getModuleEEPROMLength(ifaceName string) (uint32, error) {
+	modInfo := ethtoolModInfo{cmd: unix.ETHTOOL_GMODULEINFO}
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&modInfo)); err != nil {
+		return 0, err
+	}
+	return modInfo.eepromLen, nil
+}
+
+// getModuleEEPROM reads length bytes of the module memory as mapped by the driver
+func (s *ethtoolSocket) getModuleEEPROM(ifaceName string, offset uint32, length uint32) ([]byte, error) {
+	headerLen := uint32(unsafe.Sizeof(ethtoolEEPROMHeader{}))
+	buf := make([]byte, headerLen+length)
+	header := (*ethtoolEEPROMHeader)(unsafe.Pointer(&buf[0]))
+	header.cmd = unix.ETHTOOL_GMODULEEEPROM
+	header.offset = offset
+	header.length = length
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&buf[0])); err != nil {
+		return nil, err
+	}
+	return buf[headerLen : headerLen+header.length], nil
+}
+
+// getModuleEEPROMPage reads bytes of a page of the module memory, which must not cross the half of the page
+func (n *ethtoolNetlink) getModuleEEPROMPage(ifaceName string, page uint8, bank uint8, i2cAddress uint8, offset uint32, length uint32) ([]byte, error) {
+	u32 := func(value uint32) []byte {
+		data := make([]byte, 4)
+		nativeEndian.PutUint32(data, value)
+		return data
+	}
+	header := netlinkAttribute(unix.ETHTOOL_A_HEADER_DEV_NAME, append([]byte(ifaceName), 0))
+	attributes := netlinkAttribute(ethtoolAttrModuleEEPROMHeader|unix.NLA_F_NESTED, header)
+	attributes = append(attributes, netlinkAttribute(ethtoolAttrModuleEEPROMOffset, u32(offset))...)
+	attributes = append(attributes, netlinkAttribute(ethtoolAttrModuleEEPROMLength, u32(length))...)
+	attributes = append(attributes, netlinkAttribute(ethtoolAttrModuleEEPROMPage, []byte{page})...)
+	attributes = append(attributes, netlinkAttribute(ethtoolAttrModuleEEPROMBank, []byte{bank})...)
+	attributes = append(attributes, netlinkAttribute(ethtoolAttrModuleEEPROMI2CAddress, []byte{i2cAddress})...)
+	reply, err := n.request(unix.ETHTOOL_MSG_MODULE_EEPROM_GET, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return reply[ethtoolAttrModuleEEPROMData], nil
+}
+
+// DumpEEPROM reads the raw memory of the module of a monitored interface
+func (t *TransceiverCollector) DumpEEPROM(request EEPROMDumpRequest) ([]byte, error) {
+	namespaces, err := listNetworkNamespaces(t.networkNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(namespaces, request.Netns) {
+		return nil, ErrInterfaceNotFound
+	}
+
+	var data []byte
+	var dumpErr error
+	err = inNetworkNamespace(request.Netns, func() {
+		data, dumpErr = t.dumpEEPROM(request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, dumpErr
+}
+
+func (t *TransceiverCollector) dumpEEPROM(request EEPROMDumpRequest) ([]byte, error) {
+	ifaceNames, err := t.getMonitoredInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	if !contains(ifaceNames, request.Interface) {
+		return nil, ErrInterfaceNotFound
+	}
+	socket, err := newEthtoolSocket()
+	if err != nil {
+		return nil, fmt.Errorf("Could not open ethtool socket: %v", err)
+	}
+	defer socket.close()
+
+	if request.Paged {
+		return socket.dumpEEPROMPage(request)
+	}
+	size, err := socket.getModuleEEPROMLength(request.Interface)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read module information of interface %s: %v", request.Interface, err)
+	}
+	length, ok := dumpLength(request.Offset, request.Length, size)
+	if !ok {
+		return nil, fmt.Errorf("Offset %d and length %d exceed the module memory of %d bytes", request.Offset, length, size)
+	}
+	data, err := socket.getModuleEEPROM(request.Interface, request.Offset, length)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read module EEPROM of interface %s: %v", request.Interface, err)
+	}
+	return data, nil
+}
+
+// dumpLength returns the number of bytes to read at offset of a memory of size bytes, the rest of the memory if length
+// is 0. It is false if the range exceeds the memory, checked without adding offset and length as those may overflow.
+func dumpLength(offset uint32, length uint32, size uint32) (uint32, bool) {
+	if offset > size {
+		return 0, false
+	}
+	if length == 0 {
+		length = size - offset
+	}
+	return length, length <= size-offset
+}
+
+// dumpEEPROMPage reads a page using ethtool netlink, splitting the request at the half of the page
+func (s *ethtoolSocket) dumpEEPROMPage(request EEPROMDumpRequest) ([]byte, error) {
+	netlink := s.getNetlink()
+	if netlink == nil {
+		return nil, fmt.Errorf("Reading pages requires ethtool netlink, which is not supported by the kernel")
+	}
+	length, ok := dumpLength(request.Offset, request.Length, eepromPageSize)
+	if !ok {
+		return nil, fmt.Errorf("Offset %d and length %d exceed the page size of %d bytes", request.Offset, length, eepromPageSize)
+	}
+
+	data := []byte{}
+	for offset, end := request.Offset, request.Offset+length; offset < end; {
+		chunkEnd := end
+		page, bank := request.Page, request.Bank
+		if offset < eepromHalfPageSize {
+			// the kernel only accepts reading the lower memory shared by all pages as page 0
+			page, bank = 0, 0
+			if chunkEnd > eepromHalfPageSize {
+				chunkEnd = eepromHalfPageSize
+			}
+		}
+		chunk, err := netlink.getModuleEEPROMPage(request.Interface, page, bank, request.I2CAddress, offset, chunkEnd-offset)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read page %d bank %d of the module EEPROM of interface %s: %v", request.Page, request.Bank, request.Interface, err)
+		}
+		data = append(data, chunk...)
+		offset = chunkEnd
+	}
+	return data, nil
+}
@@ -0,0 +1,33 @@
+package transceivercollector
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDumpLength(t *testing.T) {
+	tests := []struct {
+		offset uint32
+		length uint32
+		size   uint32
+		result uint32
+		ok     bool
+	}{
+		{0, 0, 256, 256, true},
+		{128, 0, 256, 128, true},
+		{0, 16, 256, 16, true},
+		{240, 16, 256, 16, true},
+		{241, 16, 256, 0, false},
+		{257, 0, 256, 0, false},
+		{0, 257, 256, 0, false},
+		// offset + length wraps to 15
+		{16, math.MaxUint32, 256, 0, false},
+		{math.MaxUint32, 16, 256, 0, false},
+	}
+	for _, test := range tests {
+		length, ok := dumpLength(test.offset, test.length, test.size)
+		if ok != test.ok || (ok && length != test.result) {
+			t.Errorf("dumpLength(%d, %d, %d) = %d, %t, expected %d, %t", test.offset, test.length, test.size, length, ok, test.result, test.ok)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package transceivercollector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"github.com/wobcom/go-ethtool"
+)
+
+const (
+	ethtoolGetFeatures = 0x0000003a
+
+	stringSetFeatures = 4
+	// size of struct ethtool_get_features_block, holding the available, requested, active and never changed bits of 32 features
+	featureBlockSize = 16
+)
+
+// FeatureBaseline maps feature names to their expected active state
+type FeatureBaseline map[string]bool
+
+// LoadFeatureBaseline reads a feature baseline file. Each non-empty line not starting with '#' has the format
+// `<feature> <on|off>`.
+func LoadFeatureBaseline(path string) (FeatureBaseline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not open feature baseline %s", path)
+	}
+	defer file.Close()
+
+	baseline := make(FeatureBaseline)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || (fields[1] != "on" && fields[1] != "off") {
+			return nil, fmt.Errorf("%s:%d: expected `<feature> <on|off>`", path, lineNumber)
+		}
+		baseline[fields[0]] = fields[1] == "on"
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Could not read feature baseline %s", path)
+	}
+	return baseline, nil
+}
+
+// getFeatures returns the features of an interface by name. Unlike go-ethtool's Interface.GetFeatures it does
+// not require a handle, whose creation reads the module's EEPROM.
+func (s *ethtoolSocket) getFeatures(ifaceName string) (map[string]ethtool.FeatureStatus, error) {
+	length, err := s.getStringSetLength(ifaceName, stringSetFeatures)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.getStrings(ifaceName, stringSetFeatures, length)
+	if err != nil {
+		return nil, err
+	}
+	// struct ethtool_gfeatures followed by the feature blocks
+	blocks := (length + 31) / 32
+	request := make([]byte, 8+blocks*featureBlockSize)
+	nativeEndian.PutUint32(request[0:4], ethtoolGetFeatures)
+	nativeEndian.PutUint32(request[4:8], uint32(blocks))
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&request[0])); err != nil {
+		return nil, err
+	}
+	features := make(map[string]ethtool.FeatureStatus)
+	for index, name := range names {
+		block := request[8+(index/32)*featureBlockSize:]
+		bit := uint32(1) << (index % 32)
+		features[name] = ethtool.FeatureStatus{
+			Available:    nativeEndian.Uint32(block[0:4])&bit != 0,
+			Active:       nativeEndian.Uint32(block[8:12])&bit != 0,
+			NeverChanged: nativeEndian.Uint32(block[12:16])&bit != 0,
+		}
+	}
+	return features, nil
+}
+
+// featureFilter selects the interface features to export
+type featureFilter struct {
+	include  *regexp.Regexp
+	exclude  *regexp.Regexp
+	baseline FeatureBaseline
+}
+
+// matches returns true if the feature is to be exported. If a baseline is configured,
+// only features whose active state differs from the baseline are exported.
+func (f *featureFilter) matches(name string, status ethtool.FeatureStatus) bool {
+	if f.include != nil && !f.include.MatchString(name) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(name) {
+		return false
+	}
+	if f.baseline != nil {
+		expected, found := f.baseline[name]
+		return found && expected != status.Active
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package transceivercollector
+
+import (
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sys/unix"
+)
+
+const ethtoolGetFECParam = 0x00000050
+
+// Attributes of ETHTOOL_MSG_FEC_GET replies
+const (
+	ethtoolAttrFECHeader = 1
+	ethtoolAttrFECStats  = 5
+
+	ethtoolAttrFECStatPad           = 1
+	ethtoolAttrFECStatCorrected     = 2
+	ethtoolAttrFECStatUncorrectable = 3
+	ethtoolAttrFECStatCorrectedBits = 4
+)
+
+// fecModeNames maps the ETHTOOL_FEC_* bits to the names used by ethtool(8)
+var fecModeNames = []string{"none", "auto", "off", "rs", "baser", "llrs"}
+
+var (
+	fecInfoDesc                       *prometheus.Desc
+	fecCorrectedCodewordsDesc         *prometheus.Desc
+	fecUncorrectableCodewordsDesc     *prometheus.Desc
+	fecCorrectedBitsDesc              *prometheus.Desc
+	fecLaneCorrectedCodewordsDesc     *prometheus.Desc
+	fecLaneUncorrectableCodewordsDesc *prometheus.Desc
+	fecLaneCorrectedBitsDesc          *prometheus.Desc
+)
+
+func init() {
+	fecInfoDesc = newDesc("fec_info", "Active FEC mode and comma separated configured FEC modes of the interface", []string{"interface", "active_mode", "configured_modes"})
+	fecCorrectedCodewordsDesc = newDesc("fec_corrected_codewords_total", "Number of codewords corrected by FEC", []string{"interface"})
+	fecUncorrectableCodewordsDesc = newDesc("fec_uncorrectable_codewords_total", "Number of codewords FEC could not correct", []string{"interface"})
+	fecCorrectedBitsDesc = newDesc("fec_corrected_bits_total", "Number of bits corrected by FEC", []string{"interface"})
+	fecLaneCorrectedCodewordsDesc = newDesc("fec_lane_corrected_codewords_total", "Number of codewords corrected by FEC per lane", []string{"interface", "lane"})
+	fecLaneUncorrectableCodewordsDesc = newDesc("fec_lane_uncorrectable_codewords_total", "Number of codewords FEC could not correct per lane", []string{"interface", "lane"})
+	fecLaneCorrectedBitsDesc = newDesc("fec_lane_corrected_bits_total", "Number of bits corrected by FEC per lane", []string{"interface", "lane"})
+}
+
+type ethtoolFECParam struct {
+	cmd       uint32
+	activeFEC uint32
+	fec       uint32
+	reserved  uint32
+}
+
+func fecModes(mask uint32) []string {
+	modes := []string{}
+	for bit, name := range fecModeNames {
+		if mask&(1<<uint(bit)) != 0 {
+			modes = append(modes, name)
+		}
+	}
+	return modes
+}
+
+// getFECParam returns the active and the configured FEC modes of an interface
+func (s *ethtoolSocket) getFECParam(ifaceName string) (string, []string, error) {
+	param := ethtoolFECParam{cmd: ethtoolGetFECParam}
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&param)); err != nil {
+		return "", nil, err
+	}
+	return strings.Join(fecModes(param.activeFEC), ","), fecModes(param.fec), nil
+}
+
+// fecStat is a FEC counter of an interface and its lanes, if reported per lane by the driver
+type fecStat struct {
+	total uint64
+	lanes []uint64
+}
+
+// getFECStats retrieves the FEC counters of an interface, counters not supported by the driver are omitted
+func (n *ethtoolNetlink) getFECStats(ifaceName string) (map[uint16]fecStat, error) {
+	flags := make([]byte, 4)
+	nativeEndian.PutUint32(flags, unix.ETHTOOL_FLAG_STATS)
+	header := append(netlinkAttribute(unix.ETHTOOL_A_HEADER_DEV_NAME, append([]byte(ifaceName), 0)), netlinkAttribute(unix.ETHTOOL_A_HEADER_FLAGS, flags)...)
+	attributes, err := n.request(unix.ETHTOOL_MSG_FEC_GET, netlinkAttribute(ethtoolAttrFECHeader|unix.NLA_F_NESTED, header))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[uint16]fecStat)
+	for statType, data := range parseNetlinkAttributes(attributes[ethtoolAttrFECStats]) {
+		// the first value is the total, followed by the values per lane
+		if statType == ethtoolAttrFECStatPad || len(data) < 8 {
+			continue
+		}
+		stat := fecStat{total: nativeEndian.Uint64(data[0:8])}
+		for offset := 8; offset+8 <= len(data); offset += 8 {
+			stat.lanes = append(stat.lanes, nativeEndian.Uint64(data[offset:offset+8]))
+		}
+		stats[statType] = stat
+	}
+	return stats, nil
+}
+
+// exportFEC exports the FEC modes and counters of a netdev. Netdevs without FEC support are skipped silently.
+func (t *TransceiverCollector) exportFEC(ifaceName string, socket *ethtoolSocket, ch chan<- prometheus.Metric) {
+	active, configured, err := socket.getFECParam(ifaceName)
+	if err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(fecInfoDesc, prometheus.GaugeValue, 1, ifaceName, active, strings.Join(configured, ","))
+
+	netlink := socket.getNetlink()
+	if netlink == nil {
+		return
+	}
+	stats, err := netlink.getFECStats(ifaceName)
+	if err == unix.EOPNOTSUPP {
+		return
+	}
+	if err != nil {
+		t.scrapeStatus.recordError(t.netns, ifaceName, ErrorReasonFEC)
+		return
+	}
+	exportFECStat(ifaceName, stats, ethtoolAttrFECStatCorrected, fecCorrectedCodewordsDesc, fecLaneCorrectedCodewordsDesc, ch)
+	exportFECStat(ifaceName, stats, ethtoolAttrFECStatUncorrectable, fecUncorrectableCodewordsDesc, fecLaneUncorrectableCodewordsDesc, ch)
+	exportFECStat(ifaceName, stats, ethtoolAttrFECStatCorrectedBits, fecCorrectedBitsDesc, fecLaneCorrectedBitsDesc, ch)
+}
+
+func exportFECStat(ifaceName string, stats map[uint16]fecStat, statType uint16, desc *prometheus.Desc, laneDesc *prometheus.Desc, ch chan<- prometheus.Metric) {
+	stat, found := stats[statType]
+	if !found {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(stat.total), ifaceName)
+	for lane, value := range stat.lanes {
+		ch <- prometheus.MustNewConstMetric(laneDesc, prometheus.CounterValue, float64(value), ifaceName, strconv.Itoa(lane))
+	}
+}
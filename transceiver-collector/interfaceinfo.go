@@ -0,0 +1,65 @@
+package transceivercollector
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// operStates are the operational states of netdevs as reported by the kernel (RFC 2863)
+var operStates = []string{"unknown", "notpresent", "down", "lowerlayerdown", "testing", "dormant", "up"}
+
+var (
+	interfaceInfoDesc      *prometheus.Desc
+	interfaceOperStateDesc *prometheus.Desc
+	interfaceMTUDesc       *prometheus.Desc
+)
+
+func init() {
+	interfaceInfoDesc = newDesc("interface_info", "Kernel information about the interface: alias (description), current and permanent hardware address", []string{"interface", "alias", "address", "permanent_address"})
+	interfaceOperStateDesc = newDesc("interface_operstate", "1 for the operational state of the interface: unknown, notpresent, down, lowerlayerdown, testing, dormant or up", []string{"interface", "state"})
+	interfaceMTUDesc = newDesc("interface_mtu_bytes", "MTU of the interface in bytes", []string{"interface"})
+}
+
+// readSysfsAttribute returns the value of a netdev's sysfs attribute, empty if it cannot be read
+func readSysfsAttribute(ifaceName string, attribute string) string {
+	data, err := ioutil.ReadFile(filepath.Join("/sys/class/net", ifaceName, attribute))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// exportInterfaceInfo exports the kernel's information about a netdev
+func exportInterfaceInfo(ifaceName string, socket *ethtoolSocket, ch chan<- prometheus.Metric) {
+	permanentAddress := ""
+	// virtual netdevs have no permanent address or report an all zero one
+	if address, err := socket.getPermanentAddress(ifaceName); err == nil && !isZero(address) {
+		permanentAddress = net.HardwareAddr(address).String()
+	}
+	ch <- prometheus.MustNewConstMetric(interfaceInfoDesc, prometheus.GaugeValue, 1,
+		ifaceName,
+		readSysfsAttribute(ifaceName, "ifalias"),
+		readSysfsAttribute(ifaceName, "address"),
+		permanentAddress)
+
+	exportOperState(ifaceName, readSysfsAttribute(ifaceName, "operstate"), ch)
+
+	if mtu, err := strconv.ParseFloat(readSysfsAttribute(ifaceName, "mtu"), 64); err == nil {
+		ch <- prometheus.MustNewConstMetric(interfaceMTUDesc, prometheus.GaugeValue, mtu, ifaceName)
+	}
+}
+
+// exportOperState exports one series per operational state, so state changes do not create new series
+func exportOperState(ifaceName string, operState string, ch chan<- prometheus.Metric) {
+	if len(operState) == 0 {
+		return
+	}
+	for _, state := range operStates {
+		ch <- prometheus.MustNewConstMetric(interfaceOperStateDesc, prometheus.GaugeValue, boolToFloat64(state == operState), ifaceName, state)
+	}
+}
@@ -0,0 +1,41 @@
+package transceivercollector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestExportOperState(t *testing.T) {
+	ch := make(chan prometheus.Metric, len(operStates))
+	exportOperState("swp1", "up", ch)
+	close(ch)
+
+	states := map[string]float64{}
+	for metric := range ch {
+		var written dto.Metric
+		if err := metric.Write(&written); err != nil {
+			t.Fatal(err)
+		}
+		for _, label := range written.GetLabel() {
+			if label.GetName() == "state" {
+				states[label.GetValue()] = written.GetGauge().GetValue()
+			}
+		}
+	}
+	if len(states) != len(operStates) {
+		t.Errorf("exported states %v, expected one series per state", states)
+	}
+	for state, value := range states {
+		if expected := boolToFloat64(state == "up"); value != expected {
+			t.Errorf("state %s = %g, expected %g", state, value, expected)
+		}
+	}
+
+	empty := make(chan prometheus.Metric, len(operStates))
+	exportOperState("swp1", "", empty)
+	if len(empty) != 0 {
+		t.Errorf("exported %d series for an unreadable operational state, expected none", len(empty))
+	}
+}
@@ -0,0 +1,88 @@
+package transceivercollector
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Ethtool commands not provided by go-ethtool
+const (
+	ethtoolGetPermAddr = 0x00000020
+)
+
+const maxAddrLen = 32
+
+// ethtoolSocket performs ethtool ioctls not provided by go-ethtool and ethtool netlink requests
+type ethtoolSocket struct {
+	fd int
+
+	netlink            *ethtoolNetlink
+	netlinkUnavailable bool
+}
+
+type ifreq struct {
+	name [unix.IFNAMSIZ]byte
+	data uintptr
+}
+
+type ethtoolPermAddr struct {
+	cmd  uint32
+	size uint32
+	data [maxAddrLen]byte
+}
+
+func newEthtoolSocket() (*ethtoolSocket, error) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, unix.IPPROTO_IP)
+	if err != nil {
+		return nil, err
+	}
+	return &ethtoolSocket{fd: fd}, nil
+}
+
+func (s *ethtoolSocket) close() {
+	unix.Close(s.fd)
+	if s.netlink != nil {
+		s.netlink.close()
+	}
+}
+
+// getNetlink returns the ethtool netlink socket, which is opened on first use. nil if the kernel does not support ethtool netlink.
+func (s *ethtoolSocket) getNetlink() *ethtoolNetlink {
+	if s.netlink == nil && !s.netlinkUnavailable {
+		netlink, err := newEthtoolNetlink()
+		if err != nil {
+			s.netlinkUnavailable = true
+			return nil
+		}
+		s.netlink = netlink
+	}
+	return s.netlink
+}
+
+// ioctl performs the ethtool command data points to, which must start with the command's uint32 identifier
+func (s *ethtoolSocket) ioctl(ifaceName string, data unsafe.Pointer) error {
+	if len(ifaceName) >= unix.IFNAMSIZ {
+		return fmt.Errorf("Interface name %s is longer than %d characters", ifaceName, unix.IFNAMSIZ-1)
+	}
+	ifr := ifreq{data: uintptr(data)}
+	copy(ifr.name[:], ifaceName)
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(s.fd), unix.SIOCETHTOOL, uintptr(unsafe.Pointer(&ifr)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// getPermanentAddress returns the permanent hardware address of an interface
+func (s *ethtoolSocket) getPermanentAddress(ifaceName string) ([]byte, error) {
+	permAddr := ethtoolPermAddr{
+		cmd:  ethtoolGetPermAddr,
+		size: maxAddrLen,
+	}
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&permAddr)); err != nil {
+		return nil, err
+	}
+	return permAddr.data[:permAddr.size], nil
+}
@@ -0,0 +1,132 @@
+package transceivercollector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var labelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type interfaceLabelRule struct {
+	name    string
+	pattern *regexp.Regexp
+	labels  map[string]string
+}
+
+func (r *interfaceLabelRule) matches(ifaceName string) bool {
+	if r.pattern != nil {
+		return r.pattern.MatchString(ifaceName)
+	}
+	return r.name == ifaceName
+}
+
+// InterfaceLabels holds static labels attached to all metrics of matching interfaces
+type InterfaceLabels struct {
+	names []string
+	rules []interfaceLabelRule
+
+	mu    sync.Mutex
+	cache map[string]map[string]string
+}
+
+// LoadInterfaceLabels reads an interface labels file. Each non-empty line not starting with '#' has the format
+// `<interface> <label>=<value> ...`, where interface is either an interface name or a regular expression enclosed in slashes
+// matching the whole name.
+// If multiple lines match an interface, labels of later lines take precedence.
+func LoadInterfaceLabels(path string) (*InterfaceLabels, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not open interface labels %s", path)
+	}
+	defer file.Close()
+
+	interfaceLabels := &InterfaceLabels{
+		cache: make(map[string]map[string]string),
+	}
+	names := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected `<interface> <label>=<value> ...`", path, lineNumber)
+		}
+
+		rule := interfaceLabelRule{
+			name:   fields[0],
+			labels: make(map[string]string),
+		}
+		if len(fields[0]) > 2 && strings.HasPrefix(fields[0], "/") && strings.HasSuffix(fields[0], "/") {
+			rule.pattern, err = regexp.Compile("^(?:" + fields[0][1:len(fields[0])-1] + ")$")
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s:%d", path, lineNumber)
+			}
+		}
+		for _, field := range fields[1:] {
+			pair := strings.SplitN(field, "=", 2)
+			if len(pair) != 2 || !labelNameRegex.MatchString(pair[0]) || strings.HasPrefix(pair[0], "__") {
+				return nil, fmt.Errorf("%s:%d: invalid label %q", path, lineNumber, field)
+			}
+			rule.labels[pair[0]] = pair[1]
+			names[pair[0]] = true
+		}
+		interfaceLabels.rules = append(interfaceLabels.rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Could not read interface labels %s", path)
+	}
+
+	for name := range names {
+		interfaceLabels.names = append(interfaceLabels.names, name)
+	}
+	sort.Strings(interfaceLabels.names)
+	return interfaceLabels, nil
+}
+
+func (l *InterfaceLabels) isEmpty() bool {
+	return l == nil || len(l.names) == 0
+}
+
+// labelNames returns the names of all configured labels in sorted order
+func (l *InterfaceLabels) labelNames() []string {
+	if l == nil {
+		return nil
+	}
+	return l.names
+}
+
+// labelsFor returns the labels configured for an interface
+func (l *InterfaceLabels) labelsFor(ifaceName string) map[string]string {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if labels, found := l.cache[ifaceName]; found {
+		return labels
+	}
+	labels := make(map[string]string)
+	for _, rule := range l.rules {
+		if !rule.matches(ifaceName) {
+			continue
+		}
+		for name, value := range rule.labels {
+			labels[name] = value
+		}
+	}
+	l.cache[ifaceName] = labels
+	return labels
+}
@@ -0,0 +1,89 @@
+package transceivercollector
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadInterfaceLabels(t *testing.T) {
+	path := writeTestFile(t, `# comment
+/swp.*/ site=fra1 role=leaf
+
+swp1   role=uplink peer=spine1
+/.*s0/ breakout=true
+/swp1/ single=true
+swp10 url=http://x/?a=b
+`)
+	labels, err := LoadInterfaceLabels(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedNames := []string{"breakout", "peer", "role", "single", "site", "url"}
+	if names := labels.labelNames(); !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("labelNames() = %v, expected %v", names, expectedNames)
+	}
+
+	tests := []struct {
+		ifaceName string
+		labels    map[string]string
+	}{
+		// later lines take precedence
+		{"swp1", map[string]string{"site": "fra1", "role": "uplink", "peer": "spine1", "single": "true"}},
+		{"swp2", map[string]string{"site": "fra1", "role": "leaf"}},
+		// plain names and regular expressions match the whole name
+		{"swp11", map[string]string{"site": "fra1", "role": "leaf"}},
+		{"swp1s01", map[string]string{"site": "fra1", "role": "leaf"}},
+		{"swp1s0", map[string]string{"site": "fra1", "role": "leaf", "breakout": "true"}},
+		{"enp3s0", map[string]string{"breakout": "true"}},
+		// only the first '=' separates the label name from its value
+		{"swp10", map[string]string{"site": "fra1", "role": "leaf", "url": "http://x/?a=b"}},
+		{"eth0", map[string]string{}},
+	}
+	for _, test := range tests {
+		for i := 0; i < 2; i++ {
+			if found := labels.labelsFor(test.ifaceName); !reflect.DeepEqual(found, test.labels) {
+				t.Errorf("labelsFor(%q) = %v, expected %v", test.ifaceName, found, test.labels)
+			}
+		}
+	}
+}
+
+func TestLoadInterfaceLabelsErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"swp1\n", ":1: expected `<interface> <label>=<value> ...`"},
+		{"# comment\nswp1 site\n", `:2: invalid label "site"`},
+		{"swp1 1site=fra1\n", `:1: invalid label "1site=fra1"`},
+		{"swp1 site-name=fra1\n", `:1: invalid label "site-name=fra1"`},
+		{"swp1 __name__=fra1\n", `:1: invalid label "__name__=fra1"`},
+		{"/swp(/ site=fra1\n", ":1: error parsing regexp"},
+	}
+	for _, test := range tests {
+		_, err := LoadInterfaceLabels(writeTestFile(t, test.content))
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("LoadInterfaceLabels(%q) error = %v, expected it to contain %q", test.content, err, test.message)
+		}
+	}
+	if _, err := LoadInterfaceLabels(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadInterfaceLabels() of a missing file succeeded")
+	}
+}
+
+func TestInterfaceLabelsNil(t *testing.T) {
+	var labels *InterfaceLabels
+	if !labels.isEmpty() || labels.labelNames() != nil || labels.labelsFor("swp1") != nil {
+		t.Error("nil interface labels are not empty")
+	}
+
+	empty, err := LoadInterfaceLabels(writeTestFile(t, "# no labels\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !empty.isEmpty() {
+		t.Errorf("interface labels without rules are not empty: %v", empty.labelNames())
+	}
+}
@@ -0,0 +1,177 @@
+package transceivercollector
+
+import (
+	"strconv"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wobcom/go-ethtool"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ethtoolGetLinkSettings = 0x0000004c
+
+	// maxLinkModeMaskWords is the maximum number of 32 bit words per link mode mask (link_mode_masks_nwords is a s8)
+	maxLinkModeMaskWords = 127
+
+	speedUnknown = 0xffffffff
+)
+
+var duplexNames = map[uint8]string{
+	0x00: "half",
+	0x01: "full",
+}
+
+var portNames = map[uint8]string{
+	0x00: "tp",
+	0x01: "aui",
+	0x02: "bnc",
+	0x03: "mii",
+	0x04: "fibre",
+	0x05: "da",
+	0xef: "none",
+	0xff: "other",
+}
+
+var (
+	linkInfoDesc                  *prometheus.Desc
+	linkSpeedDesc                 *prometheus.Desc
+	linkAutonegotiationDesc       *prometheus.Desc
+	linkModeSupportedDesc         *prometheus.Desc
+	linkModeAdvertisedDesc        *prometheus.Desc
+	linkModePartnerAdvertisedDesc *prometheus.Desc
+)
+
+func init() {
+	linkInfoDesc = newDesc("link_info", "Negotiated duplex mode (half, full or unknown) and port type (tp, fibre, da, ...) of the interface", []string{"interface", "duplex", "port_type"})
+	linkSpeedDesc = newDesc("link_speed_megabits_per_second", "Negotiated speed of the interface in megabits per second", []string{"interface"})
+	linkAutonegotiationDesc = newDesc("link_autonegotiation_enabled_bool", "1 if autonegotiation is enabled", []string{"interface"})
+	linkModeSupportedDesc = newDesc("link_mode_supported_info", "Link mode supported by the interface", []string{"interface", "link_mode"})
+	linkModeAdvertisedDesc = newDesc("link_mode_advertised_info", "Link mode advertised by the interface", []string{"interface", "link_mode"})
+	linkModePartnerAdvertisedDesc = newDesc("link_mode_partner_advertised_info", "Link mode advertised by the link partner", []string{"interface", "link_mode"})
+}
+
+// ethtoolLinkSettings is struct ethtool_link_settings followed by the supported, advertised and link partner advertised link mode masks
+type ethtoolLinkSettings struct {
+	cmd                 uint32
+	speed               uint32
+	duplex              uint8
+	port                uint8
+	phyAddress          uint8
+	autoneg             uint8
+	mdioSupport         uint8
+	ethTpMdix           uint8
+	ethTpMdixCtrl       uint8
+	linkModeMasksNwords int8
+	transceiver         uint8
+	masterSlaveCfg      uint8
+	masterSlaveState    uint8
+	rateMatching        uint8
+	reserved            [7]uint32
+	linkModeMasks       [3 * maxLinkModeMaskWords]uint32
+}
+
+// linkSettings of an interface as reported by the driver
+type linkSettings struct {
+	speed      uint32
+	duplex     uint8
+	port       uint8
+	autoneg    bool
+	supported  []uint32
+	advertised []uint32
+	partner    []uint32
+}
+
+// getLinkSettings retrieves the link settings of an interface. The kernel reports the size of the
+// link mode masks on the first request, which is then repeated with the size set.
+func (s *ethtoolSocket) getLinkSettings(ifaceName string) (*linkSettings, error) {
+	request := ethtoolLinkSettings{cmd: ethtoolGetLinkSettings}
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&request)); err != nil {
+		return nil, err
+	}
+	words := -int(request.linkModeMasksNwords)
+	if words <= 0 || words > maxLinkModeMaskWords {
+		return nil, unix.EPROTO
+	}
+
+	request = ethtoolLinkSettings{
+		cmd:                 ethtoolGetLinkSettings,
+		linkModeMasksNwords: int8(words),
+	}
+	if err := s.ioctl(ifaceName, unsafe.Pointer(&request)); err != nil {
+		return nil, err
+	}
+	if int(request.linkModeMasksNwords) != words {
+		return nil, unix.EPROTO
+	}
+	return &linkSettings{
+		speed:      request.speed,
+		duplex:     request.duplex,
+		port:       request.port,
+		autoneg:    request.autoneg != 0,
+		supported:  request.linkModeMasks[0:words],
+		advertised: request.linkModeMasks[words : 2*words],
+		partner:    request.linkModeMasks[2*words : 3*words],
+	}, nil
+}
+
+// getLinkModeNames returns the names of the link mode bits, these are the same for all interfaces and thus retrieved once per scrape
+func (t *TransceiverCollector) getLinkModeNames(iface *ethtool.Interface) []string {
+	if t.linkModeNames == nil {
+		names, err := iface.GetStringSet(ethtool.StringSetLinkModes)
+		if err != nil {
+			return nil
+		}
+		t.linkModeNames = names
+	}
+	return t.linkModeNames
+}
+
+// exportLinkSettings exports speed, duplex, port type, autonegotiation and link modes of a netdev.
+// Drivers of virtual netdevs usually do not support link settings, which is not considered an error.
+func (t *TransceiverCollector) exportLinkSettings(ifaceName string, iface *ethtool.Interface, socket *ethtoolSocket, ch chan<- prometheus.Metric) {
+	settings, err := socket.getLinkSettings(ifaceName)
+	if err == unix.EOPNOTSUPP {
+		return
+	}
+	if err != nil {
+		t.scrapeStatus.recordError(t.netns, ifaceName, ErrorReasonLinkSettings)
+		return
+	}
+
+	duplex, found := duplexNames[settings.duplex]
+	if !found {
+		duplex = "unknown"
+	}
+	port, found := portNames[settings.port]
+	if !found {
+		port = strconv.Itoa(int(settings.port))
+	}
+	ch <- prometheus.MustNewConstMetric(linkInfoDesc, prometheus.GaugeValue, 1, ifaceName, duplex, port)
+	if settings.speed != speedUnknown {
+		ch <- prometheus.MustNewConstMetric(linkSpeedDesc, prometheus.GaugeValue, float64(settings.speed), ifaceName)
+	}
+	ch <- prometheus.MustNewConstMetric(linkAutonegotiationDesc, prometheus.GaugeValue, boolToFloat64(settings.autoneg), ifaceName)
+
+	if !t.collectLinkModes {
+		return
+	}
+	names := t.getLinkModeNames(iface)
+	exportLinkModes(ifaceName, settings.supported, names, linkModeSupportedDesc, ch)
+	exportLinkModes(ifaceName, settings.advertised, names, linkModeAdvertisedDesc, ch)
+	exportLinkModes(ifaceName, settings.partner, names, linkModePartnerAdvertisedDesc, ch)
+}
+
+func exportLinkModes(ifaceName string, mask []uint32, names []string, desc *prometheus.Desc, ch chan<- prometheus.Metric) {
+	for bit := 0; bit < len(mask)*32; bit++ {
+		if mask[bit/32]&(1<<uint(bit%32)) == 0 {
+			continue
+		}
+		name := "bit" + strconv.Itoa(bit)
+		if bit < len(names) {
+			name = names[bit]
+		}
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, ifaceName, name)
+	}
+}
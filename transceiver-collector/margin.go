@@ -0,0 +1,74 @@
+package transceivercollector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wobcom/go-ethtool/eeprom"
+)
+
+// Threshold names as exported in the threshold label of the margin metrics
+const (
+	thresholdHighAlarm   = "high_alarm"
+	thresholdHighWarning = "high_warning"
+	thresholdLowAlarm    = "low_alarm"
+	thresholdLowWarning  = "low_warning"
+)
+
+var (
+	laserTxPowerMarginDesc       *prometheus.Desc
+	laserRxPowerMarginDesc       *prometheus.Desc
+	moduleTxPowerWorstMarginDesc *prometheus.Desc
+	moduleRxPowerWorstMarginDesc *prometheus.Desc
+)
+
+func init() {
+	marginLabels := append(append([]string{}, laserLabels...), "threshold")
+	laserTxPowerMarginDesc = newDesc("laser_tx_power_margin_decibels", "Margin of the laser tx power to the given threshold in dB. Negative if the threshold is violated", marginLabels)
+	laserRxPowerMarginDesc = newDesc("laser_rx_power_margin_decibels", "Margin of the laser rx power to the given threshold in dB. Negative if the threshold is violated", marginLabels)
+	moduleTxPowerWorstMarginDesc = newDesc("module_tx_power_worst_margin_decibels", "Smallest margin of the tx power of all lasers to the given threshold in dB", []string{"interface", "threshold"})
+	moduleRxPowerWorstMarginDesc = newDesc("module_rx_power_worst_margin_decibels", "Smallest margin of the rx power of all lasers to the given threshold in dB", []string{"interface", "threshold"})
+}
+
+// powerMargins maps a threshold name to the margin of an optical power reading in dB
+type powerMargins map[string]float64
+
+// domPowerFloorMw is the smallest optical power a module can report (0.1 µW, -40 dBm)
+const domPowerFloorMw = 0.0001
+
+// getPowerMargins computes the margins of a reading in milliwatts to its thresholds.
+// Readings below the DOM floor (e.g. 0 mW of dark lanes) are clamped to it, so dark lanes violate the low thresholds.
+// Thresholds of 0 mW are skipped as they cannot be expressed in dBm.
+func getPowerMargins(valueMw float64, thresholds eeprom.AlarmThresholds) powerMargins {
+	margins := make(powerMargins)
+	if valueMw < domPowerFloorMw {
+		valueMw = domPowerFloorMw
+	}
+	value := milliwattsToDbm(valueMw)
+	if thresholds.GetHighAlarm() > 0 {
+		margins[thresholdHighAlarm] = milliwattsToDbm(thresholds.GetHighAlarm()) - value
+	}
+	if thresholds.GetHighWarning() > 0 {
+		margins[thresholdHighWarning] = milliwattsToDbm(thresholds.GetHighWarning()) - value
+	}
+	if thresholds.GetLowAlarm() > 0 {
+		margins[thresholdLowAlarm] = value - milliwattsToDbm(thresholds.GetLowAlarm())
+	}
+	if thresholds.GetLowWarning() > 0 {
+		margins[thresholdLowWarning] = value - milliwattsToDbm(thresholds.GetLowWarning())
+	}
+	return margins
+}
+
+// mergeWorst keeps the smallest margin per threshold
+func (p powerMargins) mergeWorst(other powerMargins) {
+	for threshold, margin := range other {
+		if worst, found := p[threshold]; !found || margin < worst {
+			p[threshold] = margin
+		}
+	}
+}
+
+func (p powerMargins) export(desc *prometheus.Desc, labels []string, ch chan<- prometheus.Metric) {
+	for threshold, margin := range p {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, margin, append(append([]string{}, labels...), threshold)...)
+	}
+}
@@ -0,0 +1,99 @@
+package transceivercollector
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// testThresholds implements eeprom.AlarmThresholds
+type testThresholds struct {
+	highAlarm, highWarning, lowAlarm, lowWarning float64
+}
+
+func (t testThresholds) GetHighAlarm() float64   { return t.highAlarm }
+func (t testThresholds) GetHighWarning() float64 { return t.highWarning }
+func (t testThresholds) GetLowAlarm() float64    { return t.lowAlarm }
+func (t testThresholds) GetLowWarning() float64  { return t.lowWarning }
+
+func roundMargins(margins powerMargins) powerMargins {
+	rounded := make(powerMargins)
+	for threshold, margin := range margins {
+		rounded[threshold] = math.Round(margin*100) / 100
+	}
+	return rounded
+}
+
+func TestGetPowerMargins(t *testing.T) {
+	thresholds := testThresholds{highAlarm: 2, highWarning: 1, lowAlarm: 0.01, lowWarning: 0.1}
+	tests := []struct {
+		name       string
+		valueMw    float64
+		thresholds testThresholds
+		margins    powerMargins
+	}{
+		{
+			name:       "within thresholds",
+			valueMw:    0.5,
+			thresholds: thresholds,
+			margins:    powerMargins{thresholdHighAlarm: 6.02, thresholdHighWarning: 3.01, thresholdLowAlarm: 16.99, thresholdLowWarning: 6.99},
+		},
+		{
+			name:       "low warning violated",
+			valueMw:    0.05,
+			thresholds: thresholds,
+			margins:    powerMargins{thresholdHighAlarm: 16.02, thresholdHighWarning: 13.01, thresholdLowAlarm: 6.99, thresholdLowWarning: -3.01},
+		},
+		{
+			name:       "thresholds of 0 mW are skipped",
+			valueMw:    0.5,
+			thresholds: testThresholds{highAlarm: 2, highWarning: 1},
+			margins:    powerMargins{thresholdHighAlarm: 6.02, thresholdHighWarning: 3.01},
+		},
+		{
+			name:       "dark lane is clamped to -40 dBm",
+			valueMw:    0,
+			thresholds: thresholds,
+			margins:    powerMargins{thresholdHighAlarm: 43.01, thresholdHighWarning: 40, thresholdLowAlarm: -20, thresholdLowWarning: -30},
+		},
+		{
+			name:       "negative reading",
+			valueMw:    -1,
+			thresholds: thresholds,
+			margins:    powerMargins{thresholdHighAlarm: 43.01, thresholdHighWarning: 40, thresholdLowAlarm: -20, thresholdLowWarning: -30},
+		},
+	}
+	for _, test := range tests {
+		margins := roundMargins(getPowerMargins(test.valueMw, test.thresholds))
+		if !reflect.DeepEqual(margins, test.margins) {
+			t.Errorf("%s: getPowerMargins(%v) = %v, expected %v", test.name, test.valueMw, margins, test.margins)
+		}
+	}
+}
+
+func TestPowerMarginsMergeWorst(t *testing.T) {
+	worst := make(powerMargins)
+	worst.mergeWorst(powerMargins{thresholdLowAlarm: 5, thresholdHighAlarm: 3})
+	worst.mergeWorst(powerMargins{thresholdLowAlarm: 2})
+
+	expected := powerMargins{thresholdLowAlarm: 2, thresholdHighAlarm: 3}
+	if !reflect.DeepEqual(worst, expected) {
+		t.Errorf("mergeWorst() = %v, expected %v", worst, expected)
+	}
+}
+
+func TestPowerMarginsWorstWithDarkLane(t *testing.T) {
+	thresholds := testThresholds{highAlarm: 2, highWarning: 1, lowAlarm: 0.01, lowWarning: 0.1}
+	worst := make(powerMargins)
+	// three healthy lanes and one lane in LOS
+	for _, valueMw := range []float64{0.5, 0.6, 0, 0.4} {
+		worst.mergeWorst(getPowerMargins(valueMw, thresholds))
+	}
+	worst = roundMargins(worst)
+	if worst[thresholdLowAlarm] != -20 || worst[thresholdLowWarning] != -30 {
+		t.Errorf("worst margins %v, expected the violated low thresholds of the dark lane", worst)
+	}
+	if worst[thresholdHighAlarm] != 5.23 {
+		t.Errorf("worst high alarm margin %v, expected the one of the strongest lane", worst[thresholdHighAlarm])
+	}
+}
@@ -0,0 +1,117 @@
+package transceivercollector
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wobcom/go-ethtool/eeprom"
+)
+
+var (
+	moduleInServiceDesc      *prometheus.Desc
+	moduleManufactureAgeDesc *prometheus.Desc
+)
+
+func init() {
+	moduleInServiceDesc = newDesc("module_in_service_seconds", "Time since the module was first seen by the exporter in seconds", []string{"interface"})
+	moduleManufactureAgeDesc = newDesc("module_manufacture_age_seconds", "Time since the vendor supplied date code of the module in seconds", []string{"interface"})
+}
+
+// ModuleAgeTracker remembers when modules were seen for the first time, identified by vendor, part number and serial number
+type ModuleAgeTracker struct {
+	mu        sync.Mutex
+	path      string
+	firstSeen map[string]time.Time
+}
+
+// NewModuleAgeTracker initializes a new ModuleAgeTracker. If path is not empty, first seen timestamps
+// are loaded from and persisted to the given file.
+func NewModuleAgeTracker(path string) (*ModuleAgeTracker, error) {
+	tracker := &ModuleAgeTracker{
+		path:      path,
+		firstSeen: make(map[string]time.Time),
+	}
+	if len(path) == 0 {
+		return tracker, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return tracker, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not read module state %s", path)
+	}
+	if err := json.Unmarshal(data, &tracker.firstSeen); err != nil {
+		return nil, errors.Wrapf(err, "Could not parse module state %s", path)
+	}
+	return tracker, nil
+}
+
+func moduleKey(rom eeprom.EEPROM) string {
+	if len(rom.GetVendorSN()) == 0 {
+		return ""
+	}
+	return rom.GetVendorName() + "/" + rom.GetVendorPN() + "/" + rom.GetVendorSN()
+}
+
+// getFirstSeen returns when the module was seen first, recording now if it is seen for the first time
+func (m *ModuleAgeTracker) getFirstSeen(key string, now time.Time) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if firstSeen, found := m.firstSeen[key]; found {
+		return firstSeen, nil
+	}
+	m.firstSeen[key] = now
+	return now, m.save()
+}
+
+// save writes the first seen timestamps to the state file, replacing it atomically
+func (m *ModuleAgeTracker) save() error {
+	if len(m.path) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(m.firstSeen, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(m.path), filepath.Base(m.path))
+	if err != nil {
+		return errors.Wrapf(err, "Could not write module state %s", m.path)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "Could not write module state %s", m.path)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "Could not write module state %s", m.path)
+	}
+	return errors.Wrapf(os.Rename(tmp.Name(), m.path), "Could not write module state %s", m.path)
+}
+
+// exportModuleAge exports the manufacture age and time in service of a module
+func (t *TransceiverCollector) exportModuleAge(ifaceName string, rom eeprom.EEPROM, ch chan<- prometheus.Metric, errs chan error) {
+	now := time.Now()
+	dateCode := rom.GetDateCode()
+	if !dateCode.IsZero() && dateCode.Before(now) {
+		ch <- prometheus.MustNewConstMetric(moduleManufactureAgeDesc, prometheus.GaugeValue, now.Sub(dateCode).Seconds(), ifaceName)
+	}
+
+	key := moduleKey(rom)
+	if t.moduleAgeTracker == nil || len(key) == 0 {
+		return
+	}
+	firstSeen, err := t.moduleAgeTracker.getFirstSeen(key, now)
+	if err != nil {
+		errs <- err
+	}
+	ch <- prometheus.MustNewConstMetric(moduleInServiceDesc, prometheus.GaugeValue, now.Sub(firstSeen).Seconds(), ifaceName)
+}
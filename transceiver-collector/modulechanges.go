@@ -0,0 +1,107 @@
+package transceivercollector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wobcom/go-ethtool/eeprom"
+)
+
+var (
+	moduleInsertionsDesc *prometheus.Desc
+	moduleRemovalsDesc   *prometheus.Desc
+	moduleSwapsDesc      *prometheus.Desc
+	moduleLastChangeDesc *prometheus.Desc
+)
+
+func init() {
+	moduleInsertionsDesc = newDesc("module_insertions_total", "Number of modules inserted into the interface's cage since the exporter started", []string{"interface"})
+	moduleRemovalsDesc = newDesc("module_removals_total", "Number of modules removed from the interface's cage since the exporter started", []string{"interface"})
+	moduleSwapsDesc = newDesc("module_swaps_total", "Number of modules replaced by a module with a different serial number between two scrapes", []string{"interface"})
+	moduleLastChangeDesc = newDesc("module_last_change_timestamp_seconds", "Unix time of the scrape the last insertion, removal or swap was detected at", []string{"interface"})
+}
+
+type moduleChanges struct {
+	present    bool
+	key        string
+	insertions uint64
+	removals   uint64
+	swaps      uint64
+	lastChange time.Time
+}
+
+// ModuleChangeTracker detects module insertions, removals and swaps by comparing the modules seen by consecutive scrapes
+type ModuleChangeTracker struct {
+	mu         sync.Mutex
+	interfaces map[scrapeKey]*moduleChanges
+}
+
+// NewModuleChangeTracker initializes a new ModuleChangeTracker
+func NewModuleChangeTracker() *ModuleChangeTracker {
+	return &ModuleChangeTracker{
+		interfaces: make(map[scrapeKey]*moduleChanges),
+	}
+}
+
+// observe records the state of an interface's cage. A swap is counted as a removal and an insertion as well.
+func (m *ModuleChangeTracker) observe(netns string, ifaceName string, state string, rom eeprom.EEPROM, now time.Time) moduleChanges {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	present := state == moduleStatePresent
+	key := ""
+	if present {
+		key = moduleKey(rom)
+	}
+	changes, found := m.interfaces[scrapeKey{netns, ifaceName}]
+	// unreadable modules neither count as removed nor as inserted
+	if state != moduleStatePresent && state != moduleStateEmpty {
+		if !found {
+			return moduleChanges{}
+		}
+		return *changes
+	}
+	if !found {
+		changes = &moduleChanges{present: present, key: key}
+		m.interfaces[scrapeKey{netns, ifaceName}] = changes
+		return *changes
+	}
+
+	switch {
+	case present && !changes.present:
+		changes.insertions++
+		changes.lastChange = now
+	case !present && changes.present:
+		changes.removals++
+		changes.lastChange = now
+	case present && len(key) > 0 && len(changes.key) > 0 && key != changes.key:
+		changes.swaps++
+		changes.insertions++
+		changes.removals++
+		changes.lastChange = now
+	}
+	changes.present = present
+	if !present || len(key) > 0 {
+		changes.key = key
+	}
+	return *changes
+}
+
+// exportModuleChanges exports the module insertions, removals and swaps detected for an interface
+func (t *TransceiverCollector) exportModuleChanges(ifaceName string, state string, rom eeprom.EEPROM, ch chan<- prometheus.Metric) {
+	if t.moduleChangeTracker == nil || state == moduleStateNoCage {
+		return
+	}
+	// changes are observed on every scrape, even if not exported by it
+	changes := t.moduleChangeTracker.observe(t.netns, ifaceName, state, rom, time.Now())
+	if !t.collectors.has(CollectInventory) {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(moduleInsertionsDesc, prometheus.CounterValue, float64(changes.insertions), ifaceName)
+	ch <- prometheus.MustNewConstMetric(moduleRemovalsDesc, prometheus.CounterValue, float64(changes.removals), ifaceName)
+	ch <- prometheus.MustNewConstMetric(moduleSwapsDesc, prometheus.CounterValue, float64(changes.swaps), ifaceName)
+	if !changes.lastChange.IsZero() {
+		ch <- prometheus.MustNewConstMetric(moduleLastChangeDesc, prometheus.GaugeValue, float64(changes.lastChange.UnixNano())/1e9, ifaceName)
+	}
+}
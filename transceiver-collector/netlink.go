@@ -0,0 +1,145 @@
+package transceivercollector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	genlHeaderLen  = 4
+	nlaTypeMask    = ^uint16(unix.NLA_F_NESTED | unix.NLA_F_NET_BYTEORDER)
+	netlinkBufSize = 65536
+)
+
+// nativeEndian is the byte order of netlink messages
+var nativeEndian binary.ByteOrder
+
+func init() {
+	i := uint16(1)
+	if *(*byte)(unsafe.Pointer(&i)) == 1 {
+		nativeEndian = binary.LittleEndian
+	} else {
+		nativeEndian = binary.BigEndian
+	}
+}
+
+// ethtoolNetlink performs requests to the kernel's ethtool generic netlink family (Linux 5.6+)
+type ethtoolNetlink struct {
+	fd       int
+	familyID uint16
+	seq      uint32
+}
+
+func newEthtoolNetlink() (*ethtoolNetlink, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_GENERIC)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	n := &ethtoolNetlink{fd: fd, familyID: unix.GENL_ID_CTRL}
+	attrs, err := n.request(unix.CTRL_CMD_GETFAMILY, netlinkAttribute(unix.CTRL_ATTR_FAMILY_NAME, append([]byte(unix.ETHTOOL_GENL_NAME), 0)))
+	if err != nil {
+		n.close()
+		return nil, fmt.Errorf("Could not resolve ethtool netlink family: %v", err)
+	}
+	familyID, found := attrs[unix.CTRL_ATTR_FAMILY_ID]
+	if !found || len(familyID) < 2 {
+		n.close()
+		return nil, fmt.Errorf("Could not resolve ethtool netlink family")
+	}
+	n.familyID = nativeEndian.Uint16(familyID)
+	return n, nil
+}
+
+func (n *ethtoolNetlink) close() {
+	unix.Close(n.fd)
+}
+
+// request sends a generic netlink request and returns the attributes of the reply
+func (n *ethtoolNetlink) request(cmd uint8, attributes []byte) (map[uint16][]byte, error) {
+	n.seq++
+	length := unix.SizeofNlMsghdr + genlHeaderLen + len(attributes)
+	message := make([]byte, length)
+	nativeEndian.PutUint32(message[0:4], uint32(length))
+	nativeEndian.PutUint16(message[4:6], n.familyID)
+	nativeEndian.PutUint16(message[6:8], unix.NLM_F_REQUEST)
+	nativeEndian.PutUint32(message[8:12], n.seq)
+	message[unix.SizeofNlMsghdr] = cmd
+	message[unix.SizeofNlMsghdr+1] = 1
+	copy(message[unix.SizeofNlMsghdr+genlHeaderLen:], attributes)
+	if err := unix.Sendto(n.fd, message, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, netlinkBufSize)
+	for {
+		read, _, err := unix.Recvfrom(n.fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		for data := buf[:read]; len(data) >= unix.SizeofNlMsghdr; {
+			messageLen := int(nativeEndian.Uint32(data[0:4]))
+			if messageLen < unix.SizeofNlMsghdr || messageLen > len(data) {
+				return nil, unix.EPROTO
+			}
+			messageType := nativeEndian.Uint16(data[4:6])
+			seq := nativeEndian.Uint32(data[8:12])
+			payload := data[unix.SizeofNlMsghdr:messageLen]
+			data = data[netlinkAlign(messageLen):]
+			if seq != n.seq {
+				continue
+			}
+			if messageType == unix.NLMSG_ERROR {
+				if len(payload) < 4 {
+					return nil, unix.EPROTO
+				}
+				if errno := int32(nativeEndian.Uint32(payload[0:4])); errno != 0 {
+					return nil, unix.Errno(-errno)
+				}
+				return map[uint16][]byte{}, nil
+			}
+			if len(payload) < genlHeaderLen {
+				return nil, unix.EPROTO
+			}
+			return parseNetlinkAttributes(payload[genlHeaderLen:]), nil
+		}
+	}
+}
+
+func netlinkAlign(length int) int {
+	return (length + unix.NLA_ALIGNTO - 1) & ^(unix.NLA_ALIGNTO - 1)
+}
+
+// netlinkAttribute encodes a netlink attribute including padding
+func netlinkAttribute(attributeType uint16, data []byte) []byte {
+	length := unix.SizeofNlAttr + len(data)
+	attribute := make([]byte, netlinkAlign(length))
+	nativeEndian.PutUint16(attribute[0:2], uint16(length))
+	nativeEndian.PutUint16(attribute[2:4], attributeType)
+	copy(attribute[unix.SizeofNlAttr:], data)
+	return attribute
+}
+
+// parseNetlinkAttributes maps attribute types to their data, ignoring the nested and byte order flags
+func parseNetlinkAttributes(data []byte) map[uint16][]byte {
+	attributes := make(map[uint16][]byte)
+	for len(data) >= unix.SizeofNlAttr {
+		length := int(nativeEndian.Uint16(data[0:2]))
+		if length < unix.SizeofNlAttr || length > len(data) {
+			break
+		}
+		attributes[nativeEndian.Uint16(data[2:4])&nlaTypeMask] = data[unix.SizeofNlAttr:length]
+		if netlinkAlign(length) >= len(data) {
+			break
+		}
+		data = data[netlinkAlign(length):]
+	}
+	return attributes
+}
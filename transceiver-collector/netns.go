@@ -0,0 +1,95 @@
+package transceivercollector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// NetworkNamespaceDir is the directory named network namespaces are bound to by iproute2 (`ip netns add`)
+const NetworkNamespaceDir = "/var/run/netns"
+
+// AllNetworkNamespaces selects all named network namespaces found in NetworkNamespaceDir
+const AllNetworkNamespaces = "*"
+
+// listNetworkNamespaces returns the network namespaces to collect: the exporter's own namespace (empty name) followed by
+// the configured named namespaces. If names contains AllNetworkNamespaces, all named namespaces are returned.
+func listNetworkNamespaces(names []string) ([]string, error) {
+	if !contains(names, AllNetworkNamespaces) {
+		return append([]string{""}, names...), nil
+	}
+	entries, err := ioutil.ReadDir(NetworkNamespaceDir)
+	if os.IsNotExist(err) {
+		return []string{""}, nil
+	}
+	if err != nil {
+		return []string{""}, errors.Wrapf(err, "Could not list network namespaces in %s", NetworkNamespaceDir)
+	}
+	namespaces := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			namespaces = append(namespaces, entry.Name())
+		}
+	}
+	sort.Strings(namespaces)
+	return append([]string{""}, namespaces...), nil
+}
+
+// inNetworkNamespace runs f on an OS thread switched to the named network namespace, or on the calling
+// goroutine for the exporter's own namespace (empty name). Sockets opened by f, interfaces enumerated and
+// sysfs attributes read belong to the namespace. f must not start goroutines relying on the namespace.
+func inNetworkNamespace(name string, f func()) error {
+	if len(name) == 0 {
+		f()
+		return nil
+	}
+	result := make(chan error, 1)
+	go func() {
+		// the thread is never unlocked, thus it is terminated when the goroutine exits instead of
+		// being reused with a foreign network and mount namespace
+		runtime.LockOSThread()
+		if err := enterNetworkNamespace(name); err != nil {
+			result <- err
+			return
+		}
+		f()
+		result <- nil
+	}()
+	return <-result
+}
+
+// enterNetworkNamespace switches the calling thread to the named network namespace. As sysfs shows the netdevs
+// of the namespace it was mounted in, the thread gets a private mount namespace with sysfs mounted again (as `ip netns exec` does).
+func enterNetworkNamespace(name string) error {
+	if strings.ContainsRune(name, '/') || name == "." || name == ".." {
+		return fmt.Errorf("Invalid network namespace name %q", name)
+	}
+	fd, err := unix.Open(filepath.Join(NetworkNamespaceDir, name), unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return errors.Wrapf(err, "Could not open network namespace %s", name)
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Unshare(unix.CLONE_NEWNS); err != nil {
+		return errors.Wrapf(err, "Could not create mount namespace for network namespace %s", name)
+	}
+	// do not propagate the sysfs mount below to the host
+	if err := unix.Mount("", "/", "", unix.MS_SLAVE|unix.MS_REC, ""); err != nil {
+		return errors.Wrapf(err, "Could not make mounts private for network namespace %s", name)
+	}
+	if err := unix.Setns(fd, unix.CLONE_NEWNET); err != nil {
+		return errors.Wrapf(err, "Could not enter network namespace %s", name)
+	}
+	_ = unix.Unmount("/sys", unix.MNT_DETACH)
+	if err := unix.Mount(name, "/sys", "sysfs", 0, ""); err != nil {
+		return errors.Wrapf(err, "Could not mount sysfs for network namespace %s", name)
+	}
+	return nil
+}
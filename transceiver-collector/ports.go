@@ -0,0 +1,213 @@
+package transceivercollector
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// breakoutRegex matches Cumulus style breakout netdevs, e.g. swp1s0 .. swp1s3. It is restricted to switch ports,
+// as predictable names of ordinary NICs (e.g. enp3s0) have the same form.
+var breakoutRegex = regexp.MustCompile(`^(swp\d+)s(\d+)$`)
+
+// PortMapping assigns a netdev to a physical port (transceiver cage)
+type PortMapping struct {
+	Port  string
+	Lanes []int
+	// index of the netdev within its port, in order of appearance in the port map
+	subport int
+}
+
+// PortMap maps netdev names to the physical port they belong to
+type PortMap map[string]PortMapping
+
+// LoadPortMap reads a port map file. Each non-empty line not starting with '#' has the format
+// `<interface> <port> [<lanes>]`, where lanes is a comma separated list of lane indices or ranges (e.g. `0-1`).
+// If lanes are omitted, the lanes of a module are split evenly between the netdevs of a port in order of appearance.
+func LoadPortMap(path string) (PortMap, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not open port map %s", path)
+	}
+	defer file.Close()
+
+	portMap := make(PortMap)
+	subports := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("%s:%d: expected `<interface> <port> [<lanes>]`", path, lineNumber)
+		}
+		mapping := PortMapping{
+			Port:    fields[1],
+			subport: subports[fields[1]],
+		}
+		if len(fields) == 3 {
+			mapping.Lanes, err = parseLanes(fields[2])
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s:%d", path, lineNumber)
+			}
+		}
+		subports[fields[1]]++
+		portMap[fields[0]] = mapping
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Could not read port map %s", path)
+	}
+	return portMap, nil
+}
+
+func parseLanes(raw string) ([]int, error) {
+	lanes := []int{}
+	for _, part := range strings.Split(raw, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid lane %q", part)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first {
+				return nil, fmt.Errorf("Invalid lane range %q", part)
+			}
+		}
+		for lane := first; lane <= last; lane++ {
+			lanes = append(lanes, lane)
+		}
+	}
+	return lanes, nil
+}
+
+// physicalPort is a transceiver cage shared by one or more netdevs
+type physicalPort struct {
+	name string
+	// number of netdevs the module's lanes are split between
+	width   int
+	members []portMember
+}
+
+type portMember struct {
+	ifaceName string
+	subport   int
+	lanes     []int
+}
+
+// lanesFor returns the module lanes used by the given member, nil meaning all lanes
+func (p *physicalPort) lanesFor(member int, laserCount int) []int {
+	m := p.members[member]
+	if m.lanes != nil {
+		return m.lanes
+	}
+	if p.width <= 1 {
+		return nil
+	}
+	lanesPerMember := laserCount / p.width
+	if lanesPerMember == 0 {
+		lanesPerMember = 1
+	}
+	lanes := []int{}
+	for lane := m.subport * lanesPerMember; lane < (m.subport+1)*lanesPerMember && lane < laserCount; lane++ {
+		lanes = append(lanes, lane)
+	}
+	return lanes
+}
+
+func sysfsDevice(ifaceName string) string {
+	device, err := filepath.EvalSymlinks(filepath.Join("/sys/class/net", ifaceName, "device"))
+	if err != nil {
+		return ""
+	}
+	return device
+}
+
+// groupInterfacesByPort groups the monitored netdevs by the physical port they belong to
+func (t *TransceiverCollector) groupInterfacesByPort(ifaceNames []string) []*physicalPort {
+	return groupInterfacesByPort(ifaceNames, t.breakoutDetection, t.portMap)
+}
+
+// groupInterfacesByPort groups netdevs by the physical port they belong to.
+// Netdevs are assigned using the port map first. Otherwise breakout netdevs (e.g. swp1s0) are
+// grouped if breakout detection is enabled and all netdevs of the port share the same bus device.
+func groupInterfacesByPort(ifaceNames []string, breakoutDetection bool, portMap PortMap) []*physicalPort {
+	breakoutDevices := make(map[string]string)
+	breakoutWidths := make(map[string]int)
+	if breakoutDetection {
+		interfaces, err := net.Interfaces()
+		if err == nil {
+			for _, iface := range interfaces {
+				match := breakoutRegex.FindStringSubmatch(iface.Name)
+				if match == nil {
+					continue
+				}
+				if _, mapped := portMap[iface.Name]; mapped {
+					continue
+				}
+				device := sysfsDevice(iface.Name)
+				if previous, seen := breakoutDevices[match[1]]; seen && previous != device {
+					device = ""
+				}
+				breakoutDevices[match[1]] = device
+				breakoutWidths[match[1]]++
+			}
+		}
+	}
+
+	portsByName := make(map[string]*physicalPort)
+	ports := []*physicalPort{}
+	getPort := func(name string) *physicalPort {
+		port, exists := portsByName[name]
+		if !exists {
+			port = &physicalPort{name: name}
+			portsByName[name] = port
+			ports = append(ports, port)
+		}
+		return port
+	}
+
+	for _, ifaceName := range ifaceNames {
+		if mapping, mapped := portMap[ifaceName]; mapped {
+			port := getPort(mapping.Port)
+			port.members = append(port.members, portMember{ifaceName, mapping.subport, mapping.Lanes})
+			continue
+		}
+		if match := breakoutRegex.FindStringSubmatch(ifaceName); match != nil && breakoutDevices[match[1]] != "" {
+			subport, _ := strconv.Atoi(match[2])
+			port := getPort(match[1])
+			port.width = breakoutWidths[match[1]]
+			port.members = append(port.members, portMember{ifaceName, subport, nil})
+			continue
+		}
+		port := getPort(ifaceName)
+		port.members = append(port.members, portMember{ifaceName, 0, nil})
+	}
+
+	for _, port := range ports {
+		if port.width == 0 {
+			for _, mapping := range portMap {
+				if mapping.Port == port.name {
+					port.width++
+				}
+			}
+		}
+		sort.Slice(port.members, func(i, j int) bool {
+			return port.members[i].subport < port.members[j].subport
+		})
+	}
+	return ports
+}
@@ -0,0 +1,159 @@
+package transceivercollector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestFile writes content to a file in a temporary directory and returns its path
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseLanes(t *testing.T) {
+	tests := []struct {
+		raw     string
+		lanes   []int
+		invalid bool
+	}{
+		{raw: "0", lanes: []int{0}},
+		{raw: "0,2", lanes: []int{0, 2}},
+		{raw: "0-3", lanes: []int{0, 1, 2, 3}},
+		{raw: "0-1,4-5", lanes: []int{0, 1, 4, 5}},
+		{raw: "2-2", lanes: []int{2}},
+		{raw: "", invalid: true},
+		{raw: "a", invalid: true},
+		{raw: "3-1", invalid: true},
+		{raw: "1-", invalid: true},
+		{raw: "0,,1", invalid: true},
+	}
+	for _, test := range tests {
+		lanes, err := parseLanes(test.raw)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("parseLanes(%q) = %v, expected an error", test.raw, lanes)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseLanes(%q) failed: %v", test.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(lanes, test.lanes) {
+			t.Errorf("parseLanes(%q) = %v, expected %v", test.raw, lanes, test.lanes)
+		}
+	}
+}
+
+func TestLoadPortMap(t *testing.T) {
+	path := writeTestFile(t, `# comment
+swp1s0 swp1 0
+
+swp1s1 swp1 1
+  eth4   cage4 0-1
+eth5 cage4
+`)
+	portMap, err := LoadPortMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := PortMap{
+		"swp1s0": {Port: "swp1", Lanes: []int{0}, subport: 0},
+		"swp1s1": {Port: "swp1", Lanes: []int{1}, subport: 1},
+		"eth4":   {Port: "cage4", Lanes: []int{0, 1}, subport: 0},
+		"eth5":   {Port: "cage4", subport: 1},
+	}
+	if !reflect.DeepEqual(portMap, expected) {
+		t.Errorf("LoadPortMap() = %+v, expected %+v", portMap, expected)
+	}
+}
+
+func TestLoadPortMapErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		message string
+	}{
+		{"swp1\n", ":1: expected `<interface> <port> [<lanes>]`"},
+		{"# comment\nswp1s0 swp1 0 1\n", ":2: expected"},
+		{"swp1s0 swp1 x\n", ":1: Invalid lane"},
+	}
+	for _, test := range tests {
+		_, err := LoadPortMap(writeTestFile(t, test.content))
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("LoadPortMap(%q) error = %v, expected it to contain %q", test.content, err, test.message)
+		}
+	}
+	if _, err := LoadPortMap(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadPortMap() of a missing file succeeded")
+	}
+}
+
+func TestBreakoutRegex(t *testing.T) {
+	tests := []struct {
+		ifaceName string
+		port      string
+	}{
+		{"swp1s0", "swp1"},
+		{"swp32s3", "swp32"},
+		{"swp1", ""},
+		{"enp3s0", ""},
+		{"ens1f0s1", ""},
+		{"wlp2s0", ""},
+		{"swps0", ""},
+	}
+	for _, test := range tests {
+		port := ""
+		if match := breakoutRegex.FindStringSubmatch(test.ifaceName); match != nil {
+			port = match[1]
+		}
+		if port != test.port {
+			t.Errorf("breakout port of %s = %q, expected %q", test.ifaceName, port, test.port)
+		}
+	}
+}
+
+func TestLanesFor(t *testing.T) {
+	port := &physicalPort{
+		name:  "swp1",
+		width: 4,
+		members: []portMember{
+			{"swp1s0", 0, nil},
+			{"swp1s1", 1, nil},
+			{"swp1s3", 3, []int{2, 3}},
+		},
+	}
+	tests := []struct {
+		member     int
+		laserCount int
+		lanes      []int
+	}{
+		{0, 4, []int{0}},
+		{1, 4, []int{1}},
+		{0, 8, []int{0, 1}},
+		{1, 8, []int{2, 3}},
+		// explicit lanes of the port map take precedence
+		{2, 4, []int{2, 3}},
+		// modules with fewer lanes than netdevs are shared
+		{0, 1, []int{0}},
+		{1, 1, []int{}},
+	}
+	for _, test := range tests {
+		lanes := port.lanesFor(test.member, test.laserCount)
+		if !reflect.DeepEqual(lanes, test.lanes) {
+			t.Errorf("lanesFor(%d, %d) = %v, expected %v", test.member, test.laserCount, lanes, test.lanes)
+		}
+	}
+
+	single := &physicalPort{name: "eth0", members: []portMember{{"eth0", 0, nil}}}
+	if lanes := single.lanesFor(0, 4); lanes != nil {
+		t.Errorf("lanesFor() of a port without breakout = %v, expected all lanes (nil)", lanes)
+	}
+}
@@ -0,0 +1,56 @@
+package transceivercollector
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wobcom/go-ethtool/eeprom"
+)
+
+// Module states as exported in the state label of transceiver_module_state
+const (
+	// moduleStatePresent the module's EEPROM was read
+	moduleStatePresent = "present"
+	// moduleStateEmpty the driver reports no module in the cage
+	moduleStateEmpty = "empty"
+	// moduleStateError the driver reports a module, but its EEPROM could not be read or parsed
+	moduleStateError = "error"
+	// moduleStateNoCage the interface has no cage (e.g. virtual or BASE-T interfaces)
+	moduleStateNoCage = "no_cage"
+)
+
+var moduleStates = []string{moduleStatePresent, moduleStateEmpty, moduleStateError, moduleStateNoCage}
+
+var (
+	presentDesc     *prometheus.Desc
+	moduleStateDesc *prometheus.Desc
+)
+
+func init() {
+	presentDesc = newDesc("present", "1 if a module is plugged into the interface's cage and its EEPROM could be read", []string{"interface"})
+	moduleStateDesc = newDesc("module_state", "1 for the state of the interface's cage: present, empty, error (EEPROM unreadable) or no_cage", []string{"interface", "state"})
+}
+
+// getModuleState determines the state of a cage from the result of reading the module's EEPROM.
+// Drivers report empty cages as ENODEV or ENXIO when the module info is requested, while interfaces without a cage
+// do not support the request at all. Any other error (e.g. EIO) means a plugged module could not be read.
+func getModuleState(rom eeprom.EEPROM, err error) string {
+	if rom != nil {
+		return moduleStatePresent
+	}
+	switch errors.Cause(err) {
+	case syscall.EOPNOTSUPP:
+		return moduleStateNoCage
+	case syscall.ENODEV, syscall.ENXIO:
+		return moduleStateEmpty
+	}
+	return moduleStateError
+}
+
+func exportModuleState(ifaceName string, state string, ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(presentDesc, prometheus.GaugeValue, boolToFloat64(state == moduleStatePresent), ifaceName)
+	for _, moduleState := range moduleStates {
+		ch <- prometheus.MustNewConstMetric(moduleStateDesc, prometheus.GaugeValue, boolToFloat64(state == moduleState), ifaceName, moduleState)
+	}
+}
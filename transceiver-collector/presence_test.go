@@ -0,0 +1,32 @@
+package transceivercollector
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestGetModuleState(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		state string
+	}{
+		{"ENODEV", syscall.ENODEV, moduleStateEmpty},
+		{"ENXIO", syscall.ENXIO, moduleStateEmpty},
+		{"wrapped ENODEV", errors.Wrap(syscall.ENODEV, "Could not read EEPROM"), moduleStateEmpty},
+		{"EOPNOTSUPP", syscall.EOPNOTSUPP, moduleStateNoCage},
+		// a plugged module which cannot be read is not an empty cage
+		{"EIO", syscall.EIO, moduleStateError},
+		{"wrapped EIO", errors.Wrap(syscall.EIO, "Could not read EEPROM"), moduleStateError},
+		{"ETIMEDOUT", syscall.ETIMEDOUT, moduleStateError},
+		{"parse error", fmt.Errorf("Unknown identifier"), moduleStateError},
+	}
+	for _, test := range tests {
+		if state := getModuleState(nil, test.err); state != test.state {
+			t.Errorf("%s: getModuleState() = %s, expected %s", test.name, state, test.state)
+		}
+	}
+}
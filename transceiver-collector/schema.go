@@ -0,0 +1,99 @@
+package transceivercollector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultNamespace is the namespace metrics are exported with if none is configured
+	DefaultNamespace = "transceiver"
+
+	// MetricSchemaLegacy exports the metric names of transceiver-exporter 1.x
+	MetricSchemaLegacy = 1
+	// MetricSchemaConventional exports metric names following the Prometheus naming conventions, using base units
+	MetricSchemaConventional = 2
+)
+
+// descMetadata holds the schema 1 name of a metric without namespace, its help and its labels
+type descMetadata struct {
+	name   string
+	help   string
+	labels []string
+}
+
+// descMetadataByDesc is populated during init only and read-only afterwards
+var descMetadataByDesc = make(map[*prometheus.Desc]descMetadata)
+
+// newDesc creates a descriptor using the schema 1 name of a metric
+func newDesc(name string, help string, labels []string) *prometheus.Desc {
+	desc := prometheus.NewDesc(prefix+name, help, labels, nil)
+	descMetadataByDesc[desc] = descMetadata{name, help, labels}
+	return desc
+}
+
+// conventionalName is the schema 2 name of a metric. Values are multiplied by scale and
+// fromUnit is replaced by toUnit in the help text, if the metric is converted to a base unit.
+type conventionalName struct {
+	name     string
+	scale    float64
+	fromUnit string
+	toUnit   string
+}
+
+func rename(name string) conventionalName {
+	return conventionalName{name: name, scale: 1}
+}
+
+func rescale(name string, scale float64, fromUnit string, toUnit string) conventionalName {
+	return conventionalName{name, scale, fromUnit, toUnit}
+}
+
+// conventionalNames maps schema 1 names to their schema 2 names. Metrics not listed keep their name.
+var conventionalNames = map[string]conventionalName{
+	"powerclass_info":                rename("power_class"),
+	"powerclass_watts":               rename("power_class_max_watts"),
+	"signalingrate_bauds_per_second": rename("signaling_rate_baud"),
+	"supported_link_length_meter":    rename("supported_link_length_meters"),
+	"date_code_unix_time":            rename("date_code_timestamp_seconds"),
+	"wavelength_nanometer":           rescale("wavelength_meters", 1e-9, "nanometers", "meters"),
+	"laser_wavelength_nanometer":     rescale("laser_wavelength_meters", 1e-9, "nanometers", "meters"),
+	"link_speed_megabits_per_second": rescale("link_speed_bits_per_second", 1e6, "megabits", "bits"),
+
+	"module_supports_monitoring_bool":             rename("module_supports_monitoring"),
+	"module_temperature_supports_thresholds_bool": rename("module_temperature_supports_thresholds"),
+	"module_voltage_supports_thresholds_bool":     rename("module_voltage_supports_thresholds"),
+	"laser_supports_monitoring_bool":              rename("laser_supports_monitoring"),
+	"laser_bias_current_supports_thresholds_bool": rename("laser_bias_current_supports_thresholds"),
+	"laser_tx_power_supports_thresholds_bool":     rename("laser_tx_power_supports_thresholds"),
+	"laser_rx_power_supports_thresholds_bool":     rename("laser_rx_power_supports_thresholds"),
+	"link_autonegotiation_enabled_bool":           rename("link_autonegotiation_enabled"),
+
+	"module_temperature_degrees_celsius":                        rename("module_temperature_celsius"),
+	"module_temperature_high_alarm_threshold_degrees_celsius":   rename("module_temperature_high_alarm_threshold_celsius"),
+	"module_temperature_high_warning_threshold_degrees_celsius": rename("module_temperature_high_warning_threshold_celsius"),
+	"module_temperature_low_alarm_threshold_degrees_celsius":    rename("module_temperature_low_alarm_threshold_celsius"),
+	"module_temperature_low_warning_threshold_degrees_celsius":  rename("module_temperature_low_warning_threshold_celsius"),
+
+	"module_voltage_high_alarm_threshold_voltage":   rename("module_voltage_high_alarm_threshold_volts"),
+	"module_voltage_high_warning_threshold_voltage": rename("module_voltage_high_warning_threshold_volts"),
+	"module_voltage_low_alarm_threshold_voltage":    rename("module_voltage_low_alarm_threshold_volts"),
+	"module_voltage_low_warning_threshold_voltage":  rename("module_voltage_low_warning_threshold_volts"),
+
+	"laser_bias_current_milliamperes":                        rescale("laser_bias_current_amperes", 1e-3, "milliamperes", "amperes"),
+	"laser_bias_current_high_alarm_threshold_milliamperes":   rescale("laser_bias_current_high_alarm_threshold_amperes", 1e-3, "milliamperes", "amperes"),
+	"laser_bias_current_high_warning_threshold_milliamperes": rescale("laser_bias_current_high_warning_threshold_amperes", 1e-3, "milliamperes", "amperes"),
+	"laser_bias_current_low_alarm_threshold_milliamperes":    rescale("laser_bias_current_low_alarm_threshold_amperes", 1e-3, "milliamperes", "amperes"),
+	"laser_bias_current_low_warning_threshold_milliamperes":  rescale("laser_bias_current_low_warning_threshold_amperes", 1e-3, "milliamperes", "amperes"),
+
+	"laser_tx_power_milliwatts":                        rescale("laser_tx_power_watts", 1e-3, "milliwatts", "watts"),
+	"laser_tx_power_high_alarm_threshold_milliwatts":   rescale("laser_tx_power_high_alarm_threshold_watts", 1e-3, "milliwatts", "watts"),
+	"laser_tx_power_high_warning_threshold_milliwatts": rescale("laser_tx_power_high_warning_threshold_watts", 1e-3, "milliwatts", "watts"),
+	"laser_tx_power_low_alarm_threshold_milliwatts":    rescale("laser_tx_power_low_alarm_threshold_watts", 1e-3, "milliwatts", "watts"),
+	"laser_tx_power_low_warning_threshold_milliwatts":  rescale("laser_tx_power_low_warning_threshold_watts", 1e-3, "milliwatts", "watts"),
+	"laser_rx_power_milliwatts":                        rescale("laser_rx_power_watts", 1e-3, "milliwatts", "watts"),
+	"laser_rx_power_high_alarm_threshold_milliwatts":   rescale("laser_rx_power_high_alarm_threshold_watts", 1e-3, "milliwatts", "watts"),
+	"laser_rx_power_high_warning_threshold_milliwatts": rescale("laser_rx_power_high_warning_threshold_watts", 1e-3, "milliwatts", "watts"),
+	"laser_rx_power_low_alarm_threshold_milliwatts":    rescale("laser_rx_power_low_alarm_threshold_watts", 1e-3, "milliwatts", "watts"),
+	"laser_rx_power_low_warning_threshold_milliwatts":  rescale("laser_rx_power_low_warning_threshold_watts", 1e-3, "milliwatts", "watts"),
+	"carrier_last_change_rx_power_milliwatts":          rescale("carrier_last_change_rx_power_watts", 1e-3, "milliwatts", "watts"),
+}
@@ -0,0 +1,179 @@
+package transceivercollector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons for collection errors as exported in the reason label of transceiver_scrape_errors_total
+const (
+	ErrorReasonEnumerate = "enumerate"
+	ErrorReasonEthtool   = "ethtool"
+	ErrorReasonInterface = "interface"
+	ErrorReasonFeatures  = "features"
+	ErrorReasonTopology  = "topology"
+	// ErrorReasonLinkSettings is recorded if the driver fails to report link settings it supports
+	ErrorReasonLinkSettings = "link_settings"
+	// ErrorReasonFEC is recorded if FEC statistics cannot be retrieved for an interface supporting FEC
+	ErrorReasonFEC = "fec"
+	// ErrorReasonDriverStats is recorded if the driver statistics of an interface cannot be read
+	ErrorReasonDriverStats = "driver_stats"
+	// ErrorReasonEEPROM is recorded if a module is plugged, but its EEPROM cannot be read or parsed
+	ErrorReasonEEPROM = "eeprom"
+	// ErrorReasonNetns is recorded if a network namespace cannot be listed or entered
+	ErrorReasonNetns = "netns"
+)
+
+var (
+	scrapeDurationDesc    *prometheus.Desc
+	scrapeSuccessDesc     *prometheus.Desc
+	scrapeErrorsDesc      *prometheus.Desc
+	scrapeLastSuccessDesc *prometheus.Desc
+)
+
+func init() {
+	scrapeDurationDesc = newDesc("scrape_duration_seconds", "Duration of reading the interface in seconds", []string{"interface"})
+	scrapeSuccessDesc = newDesc("scrape_success", "1 if the interface was read successfully", []string{"interface"})
+	scrapeErrorsDesc = newDesc("scrape_errors_total", "Number of errors while collecting metrics. Errors not related to a single interface have an empty interface label", []string{"interface", "reason"})
+	scrapeLastSuccessDesc = newDesc("scrape_last_success_timestamp_seconds", "Unix time of the last successful read of the interface", []string{"interface"})
+}
+
+// scrapeKey identifies an interface by network namespace and name
+type scrapeKey struct {
+	netns     string
+	ifaceName string
+}
+
+type scrapeErrorKey struct {
+	scrapeKey
+	reason string
+}
+
+// ScrapeStatus keeps track of collection errors and successful reads across scrapes
+type ScrapeStatus struct {
+	mu          sync.Mutex
+	errors      map[scrapeErrorKey]uint64
+	readErrors  map[scrapeKey]uint64
+	lastSuccess map[scrapeKey]time.Time
+	snapshot    *transceiverSnapshot
+}
+
+// transceiverSnapshot holds the transceivers decoded during a scrape of all interfaces
+type transceiverSnapshot struct {
+	readAt       time.Time
+	transceivers []Transceiver
+	errors       []NamespaceError
+}
+
+func newTransceiverSnapshot() *transceiverSnapshot {
+	return &transceiverSnapshot{
+		readAt:       time.Now(),
+		transceivers: []Transceiver{},
+		errors:       []NamespaceError{},
+	}
+}
+
+func (s *transceiverSnapshot) addTransceivers(transceivers []Transceiver) {
+	if s != nil {
+		s.transceivers = append(s.transceivers, transceivers...)
+	}
+}
+
+func (s *transceiverSnapshot) addError(netns string, err error) {
+	if s != nil {
+		s.errors = append(s.errors, NamespaceError{Netns: netns, Error: err.Error()})
+	}
+}
+
+// NewScrapeStatus initializes a new ScrapeStatus
+func NewScrapeStatus() *ScrapeStatus {
+	return &ScrapeStatus{
+		errors:      make(map[scrapeErrorKey]uint64),
+		readErrors:  make(map[scrapeKey]uint64),
+		lastSuccess: make(map[scrapeKey]time.Time),
+	}
+}
+
+func (s *ScrapeStatus) recordError(netns string, ifaceName string, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors[scrapeErrorKey{scrapeKey{netns, ifaceName}, reason}]++
+	// topology errors concern the remote exporter, not reading the interface
+	if len(ifaceName) > 0 && reason != ErrorReasonTopology {
+		s.readErrors[scrapeKey{netns, ifaceName}]++
+	}
+}
+
+// readErrorCount returns the number of errors recorded so far while reading an interface, which allows
+// to detect errors during a read by comparing the counts before and after
+func (s *ScrapeStatus) readErrorCount(netns string, ifaceName string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.readErrors[scrapeKey{netns, ifaceName}]
+}
+
+func (s *ScrapeStatus) recordSuccess(netns string, ifaceName string, timestamp time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSuccess[scrapeKey{netns, ifaceName}] = timestamp
+}
+
+// LastSuccess returns the time of the last successful read of an interface in the given network namespace (empty for the exporter's own)
+func (s *ScrapeStatus) LastSuccess(netns string, ifaceName string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	timestamp, found := s.lastSuccess[scrapeKey{netns, ifaceName}]
+	return timestamp, found
+}
+
+func (s *ScrapeStatus) recordTransceivers(snapshot *transceiverSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = snapshot
+}
+
+// Transceivers returns the transceivers and network namespace errors of the last scrape of all interfaces along with the time
+// the scrape started. The time is zero if no such scrape happened yet.
+func (s *ScrapeStatus) Transceivers() ([]Transceiver, []NamespaceError, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snapshot == nil {
+		return []Transceiver{}, []NamespaceError{}, time.Time{}
+	}
+	return s.snapshot.transceivers, s.snapshot.errors, s.snapshot.readAt
+}
+
+func (s *ScrapeStatus) describe(ch chan<- *prometheus.Desc) {
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+	ch <- scrapeErrorsDesc
+	ch <- scrapeLastSuccessDesc
+}
+
+// export exports the error counters and last successful reads of all interfaces of a network namespace seen so far.
+// The metrics are sent after releasing the lock, so a slow consumer does not block concurrent collects.
+func (s *ScrapeStatus) export(netns string, ch chan<- prometheus.Metric) {
+	for _, metric := range s.metrics(netns) {
+		ch <- metric
+	}
+}
+
+func (s *ScrapeStatus) metrics(netns string) []prometheus.Metric {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metrics := []prometheus.Metric{}
+	for key, count := range s.errors {
+		if key.netns == netns {
+			metrics = append(metrics, prometheus.MustNewConstMetric(scrapeErrorsDesc, prometheus.CounterValue, float64(count), key.ifaceName, key.reason))
+		}
+	}
+	for key, timestamp := range s.lastSuccess {
+		if key.netns == netns {
+			metrics = append(metrics, prometheus.MustNewConstMetric(scrapeLastSuccessDesc, prometheus.GaugeValue, float64(timestamp.UnixNano())/1e9, key.ifaceName))
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,123 @@
+package transceivercollector
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestScrapeStatusReadErrorCount(t *testing.T) {
+	status := NewScrapeStatus()
+	status.recordError("", "swp1", ErrorReasonEEPROM)
+	status.recordError("", "swp1", ErrorReasonFeatures)
+	status.recordError("", "swp1", ErrorReasonTopology)
+	status.recordError("", "", ErrorReasonEthtool)
+	status.recordError("blue", "swp1", ErrorReasonFEC)
+
+	tests := []struct {
+		netns     string
+		ifaceName string
+		count     uint64
+	}{
+		// topology errors concern the remote exporter
+		{"", "swp1", 2},
+		{"", "swp2", 0},
+		{"", "", 0},
+		{"blue", "swp1", 1},
+	}
+	for _, test := range tests {
+		if count := status.readErrorCount(test.netns, test.ifaceName); count != test.count {
+			t.Errorf("readErrorCount(%q, %q) = %d, expected %d", test.netns, test.ifaceName, count, test.count)
+		}
+	}
+}
+
+func TestScrapeStatusLastSuccess(t *testing.T) {
+	status := NewScrapeStatus()
+	now := time.Now()
+	status.recordSuccess("blue", "swp1", now)
+
+	if timestamp, found := status.LastSuccess("blue", "swp1"); !found || !timestamp.Equal(now) {
+		t.Errorf("LastSuccess() = %v, %t, expected %v", timestamp, found, now)
+	}
+	if _, found := status.LastSuccess("", "swp1"); found {
+		t.Error("LastSuccess() found an interface of another network namespace")
+	}
+}
+
+func TestScrapeStatusExportUnlocked(t *testing.T) {
+	status := NewScrapeStatus()
+	status.recordError("", "swp1", ErrorReasonEEPROM)
+	status.recordSuccess("", "swp2", time.Now())
+	status.recordSuccess("blue", "swp1", time.Now())
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		status.export("", ch)
+		close(ch)
+	}()
+	// export is blocked sending the second metric, recording must not wait for the consumer
+	<-ch
+	recorded := make(chan struct{})
+	go func() {
+		status.recordError("", "swp3", ErrorReasonFEC)
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("recordError() blocked while exporting")
+	}
+
+	count := 1
+	for range ch {
+		count++
+	}
+	if count != 2 {
+		t.Errorf("exported %d metrics, expected the 2 of the network namespace", count)
+	}
+}
+
+func TestScrapeStatusTransceivers(t *testing.T) {
+	status := NewScrapeStatus()
+	if transceivers, errs, readAt := status.Transceivers(); len(transceivers) != 0 || len(errs) != 0 || !readAt.IsZero() {
+		t.Errorf("Transceivers() before any scrape = %v, %v, %v, expected nothing", transceivers, errs, readAt)
+	}
+
+	collect := func(config Config) {
+		config.NetworkNamespaces = []string{"transceiver-exporter-test-missing"}
+		config.ScrapeStatus = status
+		ch := make(chan prometheus.Metric)
+		errs := make(chan error)
+		done := make(chan struct{})
+		go NewCollector(config).Collect(ch, errs, done)
+		for {
+			select {
+			case <-ch:
+			case <-errs:
+			case <-done:
+				return
+			}
+		}
+	}
+
+	collect(Config{InterfacePattern: regexp.MustCompile("^swp1$")})
+	if _, _, readAt := status.Transceivers(); !readAt.IsZero() {
+		t.Errorf("scrape of selected interfaces replaced the snapshot read at %v", readAt)
+	}
+
+	start := time.Now()
+	collect(Config{})
+	transceivers, errs, readAt := status.Transceivers()
+	if readAt.Before(start) {
+		t.Errorf("snapshot read at %v, expected after %v", readAt, start)
+	}
+	if len(errs) != 1 || errs[0].Netns != "transceiver-exporter-test-missing" {
+		t.Errorf("Transceivers() errors = %+v, expected only one of the missing network namespace", errs)
+	}
+	if transceivers == nil {
+		t.Error("Transceivers() returned no transceivers at all")
+	}
+}
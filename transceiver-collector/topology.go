@@ -0,0 +1,356 @@
+package transceivercollector
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Directions as exported in the direction label of transceiver_span_loss_decibels
+const (
+	// directionRx light sent by the remote port and received by the local port
+	directionRx = "rx"
+	// directionTx light sent by the local port and received by the remote port
+	directionTx = "tx"
+)
+
+// remotePowersExpiry is the number of polling intervals the powers of a remote exporter are used for if it cannot be fetched
+const remotePowersExpiry = 3
+
+var spanLossDesc *prometheus.Desc
+
+func init() {
+	spanLossDesc = newDesc("span_loss_decibels", "Loss of the fiber span between the local and the remote port in dB", []string{"interface", "laser_index", "direction", "remote_interface"})
+}
+
+// TopologyLink describes the remote port a local interface is cabled to
+type TopologyLink struct {
+	RemoteURL       string
+	RemoteInterface string
+}
+
+// Topology maps local interface names to the remote port they are cabled to
+type Topology map[string]TopologyLink
+
+// LoadTopology reads a topology file. Each non-empty line not starting with '#' has the format
+// `<interface> <remote exporter metrics URL> <remote interface>`.
+func LoadTopology(path string) (Topology, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not open topology %s", path)
+	}
+	defer file.Close()
+
+	topology := make(Topology)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%s:%d: expected `<interface> <remote URL> <remote interface>`", path, lineNumber)
+		}
+		topology[fields[0]] = TopologyLink{
+			RemoteURL:       fields[1],
+			RemoteInterface: fields[2],
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Could not read topology %s", path)
+	}
+	return topology, nil
+}
+
+// lanePower optical powers of a lane in dBm. Powers of dark lanes (0 mW) are not set.
+type lanePower struct {
+	tx    float64
+	txSet bool
+	rx    float64
+	rxSet bool
+}
+
+// setTxMilliwatts sets the tx power from a reading in milliwatts, unless the lane is dark
+func (p *lanePower) setTxMilliwatts(valueMw float64) {
+	if valueMw > 0 {
+		p.tx, p.txSet = milliwattsToDbm(valueMw), true
+	}
+}
+
+// setRxMilliwatts sets the rx power from a reading in milliwatts, unless the lane is dark
+func (p *lanePower) setRxMilliwatts(valueMw float64) {
+	if valueMw > 0 {
+		p.rx, p.rxSet = milliwattsToDbm(valueMw), true
+	}
+}
+
+// remotePowers maps interface names and laser indices to the optical powers reported by a remote exporter
+type remotePowers map[string]map[int]*lanePower
+
+func (r remotePowers) get(ifaceName string, index int) *lanePower {
+	if r[ifaceName] == nil {
+		r[ifaceName] = make(map[int]*lanePower)
+	}
+	if r[ifaceName][index] == nil {
+		r[ifaceName][index] = &lanePower{}
+	}
+	return r[ifaceName][index]
+}
+
+// fetchRemotePowers scrapes a remote transceiver-exporter and extracts the optical powers of all lanes
+func fetchRemotePowers(url string, timeout time.Duration) (remotePowers, error) {
+	client := http.Client{Timeout: timeout}
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not fetch %s", url)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Could not fetch %s: %s", url, response.Status)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(response.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not parse metrics of %s", url)
+	}
+
+	powers := make(remotePowers)
+	for name, family := range families {
+		// the remote may use any namespace and metric schema
+		var convert func(float64) float64
+		switch {
+		case hasAnySuffix(name, "laser_tx_power_milliwatts", "laser_rx_power_milliwatts"):
+			convert = milliwattsToDbm
+		case hasAnySuffix(name, "laser_tx_power_watts", "laser_rx_power_watts"):
+			convert = func(watts float64) float64 { return milliwattsToDbm(watts * 1000) }
+		case hasAnySuffix(name, "laser_tx_power_dbm", "laser_rx_power_dbm"):
+			convert = func(dbm float64) float64 { return dbm }
+		default:
+			continue
+		}
+		isTx := strings.Contains(name, "_tx_")
+		for _, metric := range family.GetMetric() {
+			ifaceName, index, ok := getLaneLabels(metric)
+			if !ok {
+				continue
+			}
+			value := convert(getMetricValue(metric))
+			// dark lanes (0 mW, -Inf dBm) have no power to compute a span loss from
+			if math.IsInf(value, 0) || math.IsNaN(value) {
+				continue
+			}
+			power := powers.get(ifaceName, index)
+			if isTx {
+				power.tx, power.txSet = value, true
+			} else {
+				power.rx, power.rxSet = value, true
+			}
+		}
+	}
+	return powers, nil
+}
+
+// getMetricValue returns the value of a gauge, or of an untyped metric if the remote omits the type
+func getMetricValue(metric *dto.Metric) float64 {
+	if metric.Untyped != nil {
+		return metric.GetUntyped().GetValue()
+	}
+	return metric.GetGauge().GetValue()
+}
+
+func getLaneLabels(metric *dto.Metric) (string, int, bool) {
+	ifaceName := ""
+	index := -1
+	for _, label := range metric.GetLabel() {
+		switch label.GetName() {
+		case "interface":
+			ifaceName = label.GetValue()
+		case "laser_index":
+			parsed, err := strconv.Atoi(label.GetValue())
+			if err != nil {
+				return "", 0, false
+			}
+			index = parsed
+		}
+	}
+	return ifaceName, index, len(ifaceName) > 0 && index >= 0
+}
+
+// remotePowersEntry is the result of fetching a remote exporter
+type remotePowersEntry struct {
+	// powers of the last successful fetch, nil if none succeeded so far
+	powers    remotePowers
+	fetchedAt time.Time
+	// err of the last fetch
+	err error
+}
+
+// RemotePowerCache fetches the optical powers of the remote exporters listed in the topology in the background.
+// Scrapes thus neither wait for remote exporters nor scrape each other in turn if exporters list each other.
+type RemotePowerCache struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	timeout time.Duration
+	urls    []string
+	entries map[string]*remotePowersEntry
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewRemotePowerCache initializes a new RemotePowerCache fetching the remote exporters every interval.
+// The powers of a remote exporter expire if it could not be fetched for three intervals.
+func NewRemotePowerCache(interval time.Duration) *RemotePowerCache {
+	return &RemotePowerCache{
+		interval: interval,
+		entries:  make(map[string]*remotePowersEntry),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// SetTopology sets the remote exporters to fetch and the timeout of fetching them from the next poll on,
+// forgetting the powers of remote exporters no longer listed
+func (c *RemotePowerCache) SetTopology(topology Topology, timeout time.Duration) {
+	urls := []string{}
+	for _, link := range topology {
+		if !contains(urls, link.RemoteURL) {
+			urls = append(urls, link.RemoteURL)
+		}
+	}
+	sort.Strings(urls)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.timeout = timeout
+	c.urls = urls
+	for url := range c.entries {
+		if !contains(urls, url) {
+			delete(c.entries, url)
+		}
+	}
+}
+
+// Start starts fetching in the background
+func (c *RemotePowerCache) Start() {
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			c.poll()
+			select {
+			case <-ticker.C:
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops fetching and waits for running fetches to finish
+func (c *RemotePowerCache) Stop() {
+	close(c.stop)
+	<-c.done
+}
+
+// poll fetches all remote exporters concurrently
+func (c *RemotePowerCache) poll() {
+	c.mu.Lock()
+	urls, timeout := c.urls, c.timeout
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, url := range urls {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			powers, err := fetchRemotePowers(url, timeout)
+			c.update(url, powers, err, time.Now())
+		}(url)
+	}
+	wg.Wait()
+}
+
+func (c *RemotePowerCache) update(url string, powers remotePowers, err error, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the topology may have changed while fetching
+	if !contains(c.urls, url) {
+		return
+	}
+	entry, found := c.entries[url]
+	if !found {
+		entry = &remotePowersEntry{}
+		c.entries[url] = entry
+	}
+	entry.err = err
+	if err == nil {
+		entry.powers = powers
+		entry.fetchedAt = now
+	}
+}
+
+// get returns the powers last fetched from a remote exporter unless they expired, nil if it has not been fetched yet
+func (c *RemotePowerCache) get(url string, now time.Time) (remotePowers, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[url]
+	if !found {
+		return nil, nil
+	}
+	if entry.powers == nil || now.Sub(entry.fetchedAt) > remotePowersExpiry*c.interval {
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return nil, fmt.Errorf("Metrics of %s last fetched at %s expired", url, entry.fetchedAt.Format(time.RFC3339))
+	}
+	return entry.powers, nil
+}
+
+// exportSpanLoss exports the span loss of both directions between a local interface and the remote port it is cabled to
+func (t *TransceiverCollector) exportSpanLoss(ifaceName string, localPowers map[int]*lanePower, ch chan<- prometheus.Metric, errs chan error) {
+	link, found := t.topology[ifaceName]
+	if !found || t.remotePowerCache == nil {
+		return
+	}
+	remote, err := t.remotePowerCache.get(link.RemoteURL, time.Now())
+	if err != nil {
+		t.scrapeStatus.recordError(t.netns, ifaceName, ErrorReasonTopology)
+		errs <- err
+		return
+	}
+
+	for index, local := range localPowers {
+		peer, found := remote[link.RemoteInterface][index]
+		if !found {
+			continue
+		}
+		laserIndex := strconv.Itoa(index)
+		if peer.txSet && local.rxSet {
+			ch <- prometheus.MustNewConstMetric(spanLossDesc, prometheus.GaugeValue, peer.tx-local.rx, ifaceName, laserIndex, directionRx, link.RemoteInterface)
+		}
+		if local.txSet && peer.rxSet {
+			ch <- prometheus.MustNewConstMetric(spanLossDesc, prometheus.GaugeValue, local.tx-peer.rx, ifaceName, laserIndex, directionTx, link.RemoteInterface)
+		}
+	}
+}
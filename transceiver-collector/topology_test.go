@@ -0,0 +1,299 @@
+package transceivercollector
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// standInExporter serves the given metrics like a remote transceiver-exporter and counts the requests
+func standInExporter(t *testing.T, metrics string) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprint(w, metrics)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+const standInMetrics = `# HELP transceiver_laser_tx_power_milliwatts Laser tx power in milliwatts
+# TYPE transceiver_laser_tx_power_milliwatts gauge
+transceiver_laser_tx_power_milliwatts{interface="swp32",laser_index="0",port="swp32"} 1
+transceiver_laser_tx_power_milliwatts{interface="swp32",laser_index="1",port="swp32"} 0.5
+# HELP transceiver_laser_rx_power_milliwatts Laser rx power in milliwatts
+# TYPE transceiver_laser_rx_power_milliwatts gauge
+transceiver_laser_rx_power_milliwatts{interface="swp32",laser_index="0",port="swp32"} 0.25
+# HELP transceiver_laser_temperature_celsius Laser temperature
+# TYPE transceiver_laser_temperature_celsius gauge
+transceiver_laser_temperature_celsius{interface="swp32",laser_index="0"} 40
+`
+
+func roundDecibels(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func TestLoadTopology(t *testing.T) {
+	path := writeTestFile(t, `# comment
+swp1 http://spine1:9458/metrics swp32
+
+swp2	http://spine2:9458/metrics   swp31
+`)
+	topology, err := LoadTopology(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Topology{
+		"swp1": {RemoteURL: "http://spine1:9458/metrics", RemoteInterface: "swp32"},
+		"swp2": {RemoteURL: "http://spine2:9458/metrics", RemoteInterface: "swp31"},
+	}
+	if !reflect.DeepEqual(topology, expected) {
+		t.Errorf("LoadTopology() = %+v, expected %+v", topology, expected)
+	}
+
+	for _, content := range []string{"swp1 http://spine1:9458/metrics\n", "swp1 http://spine1:9458/metrics swp32 extra\n"} {
+		_, err := LoadTopology(writeTestFile(t, content))
+		if err == nil || !strings.Contains(err.Error(), ":1: expected") {
+			t.Errorf("LoadTopology(%q) error = %v, expected a syntax error of line 1", content, err)
+		}
+	}
+	if _, err := LoadTopology(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadTopology() of a missing file succeeded")
+	}
+}
+
+func TestFetchRemotePowers(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics string
+		powers  map[int]lanePower
+	}{
+		{
+			name:    "milliwatts",
+			metrics: standInMetrics,
+			powers: map[int]lanePower{
+				0: {tx: 0, txSet: true, rx: -6.02, rxSet: true},
+				1: {tx: -3.01, txSet: true},
+			},
+		},
+		{
+			name: "schema 2 with custom namespace",
+			metrics: `optics_laser_tx_power_watts{interface="swp32",laser_index="0"} 0.001
+optics_laser_rx_power_watts{interface="swp32",laser_index="0"} 0.0005
+`,
+			powers: map[int]lanePower{0: {tx: 0, txSet: true, rx: -3.01, rxSet: true}},
+		},
+		{
+			name: "dBm",
+			metrics: `transceiver_laser_tx_power_dbm{interface="swp32",laser_index="0"} -1.5
+transceiver_laser_rx_power_dbm{interface="swp32",laser_index="0"} -4
+`,
+			powers: map[int]lanePower{0: {tx: -1.5, txSet: true, rx: -4, rxSet: true}},
+		},
+		{
+			name: "dark lanes are skipped",
+			metrics: `transceiver_laser_tx_power_milliwatts{interface="swp32",laser_index="0"} 0
+transceiver_laser_rx_power_milliwatts{interface="swp32",laser_index="0"} 0.25
+transceiver_laser_tx_power_dbm{interface="swp32",laser_index="1"} -Inf
+transceiver_laser_rx_power_watts{interface="swp32",laser_index="2"} 0
+`,
+			powers: map[int]lanePower{0: {rx: -6.02, rxSet: true}},
+		},
+		{
+			name: "lanes without labels are skipped",
+			metrics: `transceiver_laser_tx_power_dbm{interface="swp32"} -1.5
+transceiver_laser_tx_power_dbm{interface="swp32",laser_index="x"} -1.5
+transceiver_laser_tx_power_dbm{laser_index="0"} -1.5
+`,
+			powers: map[int]lanePower{},
+		},
+	}
+	for _, test := range tests {
+		server, _ := standInExporter(t, test.metrics)
+		remote, err := fetchRemotePowers(server.URL, time.Second)
+		if err != nil {
+			t.Errorf("%s: fetchRemotePowers() failed: %v", test.name, err)
+			continue
+		}
+		powers := map[int]lanePower{}
+		for index, power := range remote["swp32"] {
+			powers[index] = lanePower{roundDecibels(power.tx), power.txSet, roundDecibels(power.rx), power.rxSet}
+		}
+		if !reflect.DeepEqual(powers, test.powers) {
+			t.Errorf("%s: fetchRemotePowers() = %+v, expected %+v", test.name, powers, test.powers)
+		}
+	}
+}
+
+func TestFetchRemotePowersErrors(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	if _, err := fetchRemotePowers(notFound.URL, time.Second); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("fetchRemotePowers() of a missing page error = %v, expected status 404", err)
+	}
+
+	invalid, _ := standInExporter(t, "transceiver_laser_tx_power_dbm{interface=\n")
+	if _, err := fetchRemotePowers(invalid.URL, time.Second); err == nil || !strings.Contains(err.Error(), "Could not parse") {
+		t.Errorf("fetchRemotePowers() of invalid metrics error = %v, expected a parse error", err)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		<-request.Context().Done()
+	}))
+	defer slow.Close()
+	start := time.Now()
+	if _, err := fetchRemotePowers(slow.URL, 100*time.Millisecond); err == nil {
+		t.Error("fetchRemotePowers() of a hanging exporter succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("fetchRemotePowers() took %v despite a timeout of 100ms", elapsed)
+	}
+}
+
+func TestRemotePowerCache(t *testing.T) {
+	server, _ := standInExporter(t, standInMetrics)
+	cache := NewRemotePowerCache(time.Minute)
+	cache.SetTopology(Topology{
+		"swp1": {RemoteURL: server.URL, RemoteInterface: "swp32"},
+		"swp2": {RemoteURL: server.URL, RemoteInterface: "swp31"},
+	}, time.Second)
+
+	now := time.Now()
+	if powers, err := cache.get(server.URL, now); powers != nil || err != nil {
+		t.Errorf("get() before fetching = %v, %v, expected nothing", powers, err)
+	}
+
+	cache.poll()
+	powers, err := cache.get(server.URL, time.Now())
+	if err != nil || powers["swp32"][0] == nil {
+		t.Fatalf("get() after fetching = %v, %v, expected the powers of swp32", powers, err)
+	}
+
+	// the last powers are used while the remote exporter is unavailable, until they expire
+	server.Close()
+	cache.poll()
+	if powers, err := cache.get(server.URL, time.Now()); err != nil || powers == nil {
+		t.Errorf("get() after a failed fetch = %v, %v, expected the previous powers", powers, err)
+	}
+	if powers, err := cache.get(server.URL, time.Now().Add(remotePowersExpiry*time.Minute+time.Second)); err == nil {
+		t.Errorf("get() after expiry = %v, expected an error", powers)
+	}
+
+	cache.SetTopology(Topology{}, time.Second)
+	if powers, err := cache.get(server.URL, time.Now()); powers != nil || err != nil {
+		t.Errorf("get() of a remote exporter removed from the topology = %v, %v, expected nothing", powers, err)
+	}
+}
+
+func TestRemotePowerCacheFailedFirstFetch(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	cache := NewRemotePowerCache(time.Minute)
+	cache.SetTopology(Topology{"swp1": {RemoteURL: notFound.URL, RemoteInterface: "swp32"}}, time.Second)
+	cache.poll()
+	if _, err := cache.get(notFound.URL, time.Now()); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("get() after a failed first fetch error = %v, expected the fetch error", err)
+	}
+}
+
+// collectSpanLoss runs exportSpanLoss and returns the exported span losses by laser index and direction
+func collectSpanLoss(t *testing.T, collector *TransceiverCollector, localPowers map[int]*lanePower) (map[string]float64, []error) {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 100)
+	errs := make(chan error, 100)
+	collector.exportSpanLoss("swp1", localPowers, ch, errs)
+	close(ch)
+	close(errs)
+
+	losses := map[string]float64{}
+	for metric := range ch {
+		var written dto.Metric
+		if err := metric.Write(&written); err != nil {
+			t.Fatal(err)
+		}
+		labels := map[string]string{}
+		for _, label := range written.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["remote_interface"] != "swp32" {
+			t.Errorf("span loss with remote interface %q, expected swp32", labels["remote_interface"])
+		}
+		losses[labels["laser_index"]+"/"+labels["direction"]] = roundDecibels(written.GetGauge().GetValue())
+	}
+	collectedErrs := []error{}
+	for err := range errs {
+		collectedErrs = append(collectedErrs, err)
+	}
+	return losses, collectedErrs
+}
+
+func TestExportSpanLoss(t *testing.T) {
+	server, requests := standInExporter(t, standInMetrics)
+	topology := Topology{"swp1": {RemoteURL: server.URL, RemoteInterface: "swp32"}}
+	cache := NewRemotePowerCache(time.Minute)
+	cache.SetTopology(topology, time.Second)
+	collector := &TransceiverCollector{topology: topology, remotePowerCache: cache, scrapeStatus: NewScrapeStatus()}
+	localPowers := map[int]*lanePower{
+		0: {tx: milliwattsToDbm(0.5), txSet: true, rx: milliwattsToDbm(0.25), rxSet: true},
+		1: {rx: milliwattsToDbm(0.125), rxSet: true},
+	}
+
+	// scrapes never fetch remote exporters themselves, which would make exporters listing each other scrape each other in turn
+	losses, errs := collectSpanLoss(t, collector, localPowers)
+	if len(losses) != 0 || len(errs) != 0 || atomic.LoadInt32(requests) != 0 {
+		t.Errorf("exportSpanLoss() before fetching = %v, %v with %d requests, expected nothing", losses, errs, atomic.LoadInt32(requests))
+	}
+
+	cache.poll()
+	losses, errs = collectSpanLoss(t, collector, localPowers)
+	expected := map[string]float64{
+		// remote tx 1 mW, local rx 0.25 mW
+		"0/rx": 6.02,
+		// local tx 0.5 mW, remote rx 0.25 mW
+		"0/tx": 3.01,
+		// remote tx 0.5 mW, local rx 0.125 mW
+		"1/rx": 6.02,
+	}
+	if len(errs) != 0 || !reflect.DeepEqual(losses, expected) {
+		t.Errorf("exportSpanLoss() = %v, %v, expected %v", losses, errs, expected)
+	}
+	if count := atomic.LoadInt32(requests); count != 1 {
+		t.Errorf("remote exporter fetched %d times, expected once", count)
+	}
+
+	// dark lanes on either side have no span loss
+	dark := map[int]*lanePower{0: {}, 1: {}}
+	dark[0].setTxMilliwatts(0.5)
+	dark[0].setRxMilliwatts(0)
+	dark[1].setRxMilliwatts(0.125)
+	losses, errs = collectSpanLoss(t, collector, dark)
+	expected = map[string]float64{"0/tx": 3.01, "1/rx": 6.02}
+	if len(errs) != 0 || !reflect.DeepEqual(losses, expected) {
+		t.Errorf("exportSpanLoss() with a dark local lane = %v, %v, expected %v", losses, errs, expected)
+	}
+
+	server.Close()
+	cache.poll()
+	for url := range cache.entries {
+		cache.entries[url].fetchedAt = time.Now().Add(-remotePowersExpiry*time.Minute - time.Second)
+	}
+	losses, errs = collectSpanLoss(t, collector, localPowers)
+	if len(losses) != 0 || len(errs) != 1 {
+		t.Errorf("exportSpanLoss() with expired powers = %v, %v, expected a single error", losses, errs)
+	}
+	if count := collector.scrapeStatus.errors[scrapeErrorKey{scrapeKey{"", "swp1"}, ErrorReasonTopology}]; count != 1 {
+		t.Errorf("recorded %d topology errors, expected 1", count)
+	}
+}
@@ -0,0 +1,303 @@
+package transceivercollector
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wobcom/go-ethtool"
+	"github.com/wobcom/go-ethtool/eeprom"
+)
+
+// Transceiver is the decoded state of an interface's module as returned by the JSON API
+type Transceiver struct {
+	Interface string `json:"interface"`
+	// Netns is the named network namespace of the interface, empty for the exporter's own
+	Netns string `json:"netns,omitempty"`
+	// Port is the physical port (cage) the interface belongs to, see breakout ports
+	Port string `json:"port"`
+	// State is the state of the cage as exported by transceiver_module_state
+	State      string            `json:"state"`
+	Driver     *DriverInfo       `json:"driver,omitempty"`
+	Module     *ModuleInfo       `json:"module,omitempty"`
+	Monitoring *ModuleMonitoring `json:"monitoring,omitempty"`
+	// Lanes are the lanes of the module used by the interface
+	Lanes []Lane `json:"lanes,omitempty"`
+	// Error describes why the interface or its module could not be read
+	Error  string    `json:"error,omitempty"`
+	ReadAt time.Time `json:"read_at"`
+}
+
+// DriverInfo is the driver information of an interface
+type DriverInfo struct {
+	Name                string `json:"name"`
+	Version             string `json:"version"`
+	FirmwareVersion     string `json:"firmware_version"`
+	BusInfo             string `json:"bus_info"`
+	ExpansionRomVersion string `json:"expansion_rom_version"`
+}
+
+// ModuleInfo are the decoded identity and static properties of a module
+type ModuleInfo struct {
+	Identifier         string  `json:"identifier"`
+	Connector          string  `json:"connector"`
+	Encoding           string  `json:"encoding"`
+	VendorName         string  `json:"vendor_name"`
+	VendorPartNumber   string  `json:"vendor_part_number"`
+	VendorRevision     string  `json:"vendor_revision"`
+	VendorSerialNumber string  `json:"vendor_serial_number"`
+	VendorOUI          string  `json:"vendor_oui"`
+	DateCode           string  `json:"date_code,omitempty"`
+	PowerClass         int     `json:"power_class"`
+	PowerClassWatts    float64 `json:"power_class_watts"`
+	SignalingRate      float64 `json:"signaling_rate_bauds_per_second"`
+	Wavelength         float64 `json:"wavelength_nanometers"`
+	// SupportedLinkLengths maps media to the maximum supported link length in meters
+	SupportedLinkLengths map[string]float64 `json:"supported_link_lengths_meters,omitempty"`
+	SupportsMonitoring   bool               `json:"supports_monitoring"`
+}
+
+// ModuleMonitoring are the module wide real time monitoring values
+type ModuleMonitoring struct {
+	Temperature *Measurement `json:"temperature_celsius,omitempty"`
+	Voltage     *Measurement `json:"voltage_volts,omitempty"`
+}
+
+// Lane is a lane (laser) of a module
+type Lane struct {
+	Index              int          `json:"index"`
+	Wavelength         float64      `json:"wavelength_nanometers,omitempty"`
+	SupportsMonitoring bool         `json:"supports_monitoring"`
+	BiasCurrent        *Measurement `json:"bias_current_milliamperes,omitempty"`
+	TxPower            *Measurement `json:"tx_power_milliwatts,omitempty"`
+	TxPowerDbm         *Measurement `json:"tx_power_dbm,omitempty"`
+	RxPower            *Measurement `json:"rx_power_milliwatts,omitempty"`
+	RxPowerDbm         *Measurement `json:"rx_power_dbm,omitempty"`
+}
+
+// Measurement is a monitored value along with the module's alarm and warning thresholds, if supported
+type Measurement struct {
+	Value      float64     `json:"value"`
+	Thresholds *Thresholds `json:"thresholds,omitempty"`
+}
+
+// Thresholds are the alarm and warning thresholds of a measurement
+type Thresholds struct {
+	HighAlarm   float64 `json:"high_alarm"`
+	HighWarning float64 `json:"high_warning"`
+	LowAlarm    float64 `json:"low_alarm"`
+	LowWarning  float64 `json:"low_warning"`
+}
+
+// NamespaceError is an error preventing the interfaces of a network namespace from being read
+type NamespaceError struct {
+	// Netns is the named network namespace, empty for the exporter's own and '*' if the namespaces could not be listed
+	Netns string `json:"netns"`
+	Error string `json:"error"`
+}
+
+// Transceivers reads the modules of all monitored interfaces in all configured network namespaces.
+// Network namespaces which cannot be entered or read are returned as errors, while the other namespaces are still read.
+// Errors of single interfaces are reported in Transceiver.Error.
+func (t *TransceiverCollector) Transceivers() ([]Transceiver, []NamespaceError) {
+	transceivers := []Transceiver{}
+	errs := []NamespaceError{}
+	namespaces, err := listNetworkNamespaces(t.networkNamespaces)
+	if err != nil {
+		errs = append(errs, NamespaceError{Netns: AllNetworkNamespaces, Error: err.Error()})
+	}
+	for _, netns := range namespaces {
+		collector := *t
+		collector.netns = netns
+		var readErr error
+		err := inNetworkNamespace(netns, func() {
+			var read []Transceiver
+			read, readErr = collector.readTransceivers()
+			transceivers = append(transceivers, read...)
+		})
+		if err == nil {
+			err = readErr
+		}
+		if err != nil {
+			errs = append(errs, NamespaceError{Netns: netns, Error: err.Error()})
+		}
+	}
+	return transceivers, errs
+}
+
+func (t *TransceiverCollector) readTransceivers() ([]Transceiver, error) {
+	ifaceNames, err := t.getMonitoredInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	tool, err := ethtool.NewEthtool()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not instanciate ethtool")
+	}
+	defer tool.Close()
+
+	transceivers := []Transceiver{}
+	for _, port := range t.groupInterfacesByPort(ifaceNames) {
+		transceivers = append(transceivers, t.readPort(tool, port)...)
+	}
+	return transceivers, nil
+}
+
+// readPort reads the transceiver of a physical port once and returns it for every netdev using it
+func (t *TransceiverCollector) readPort(tool *ethtool.Ethtool, port *physicalPort) []Transceiver {
+	now := time.Now()
+	primary, err := tool.NewInterface(port.members[0].ifaceName, false)
+	var eepromErr error
+	if err != nil && primary != nil && primary.DriverInfo != nil {
+		eepromErr, err = err, nil
+	}
+	return t.portTransceivers(port, primary, err, eepromErr, now)
+}
+
+// portTransceivers decodes the transceiver of a physical port read at the given time for every netdev using it.
+// err is the error reading the interface, eepromErr the one reading the module's EEPROM.
+func (t *TransceiverCollector) portTransceivers(port *physicalPort, primary *ethtool.Interface, err error, eepromErr error, now time.Time) []Transceiver {
+	transceivers := []Transceiver{}
+	for index, member := range port.members {
+		transceiver := Transceiver{
+			Interface: member.ifaceName,
+			Netns:     t.netns,
+			Port:      port.name,
+			ReadAt:    now,
+		}
+		if err != nil || primary == nil {
+			transceiver.State = moduleStateError
+			transceiver.Error = fmt.Sprintf("Error fetching information for interface %s: %v", member.ifaceName, err)
+			transceivers = append(transceivers, transceiver)
+			continue
+		}
+		transceiver.State = getModuleState(primary.Eeprom, eepromErr)
+		if transceiver.State == moduleStateError {
+			transceiver.Error = fmt.Sprintf("Error reading EEPROM of interface %s: %v", member.ifaceName, eepromErr)
+		}
+		transceiver.Driver = newDriverInfo(primary.DriverInfo)
+		if rom := primary.Eeprom; rom != nil {
+			transceiver.Module = getModuleInfo(rom)
+			transceiver.Monitoring, transceiver.Lanes = getModuleMonitoring(rom, port.lanesFor(index, len(rom.GetLasers())))
+		}
+		transceivers = append(transceivers, transceiver)
+	}
+	return transceivers
+}
+
+// newDriverInfo converts the driver information reported by ethtool, as exported by the API and the metrics
+func newDriverInfo(driverInfo *ethtool.DriverInfo) *DriverInfo {
+	return &DriverInfo{
+		Name:                truncateAtNUL(driverInfo.DriverName),
+		Version:             truncateAtNUL(driverInfo.DriverVersion),
+		FirmwareVersion:     truncateAtNUL(driverInfo.FirmwareVersion),
+		BusInfo:             truncateAtNUL(driverInfo.BusInfo),
+		ExpansionRomVersion: truncateAtNUL(driverInfo.ExpansionRomVersion),
+	}
+}
+
+func getModuleInfo(rom eeprom.EEPROM) *ModuleInfo {
+	info := &ModuleInfo{
+		Identifier:           rom.GetIdentifier().String(),
+		Connector:            rom.GetConnectorType().String(),
+		Encoding:             rom.GetEncoding(),
+		VendorName:           rom.GetVendorName(),
+		VendorPartNumber:     rom.GetVendorPN(),
+		VendorRevision:       rom.GetVendorRev(),
+		VendorSerialNumber:   rom.GetVendorSN(),
+		VendorOUI:            rom.GetVendorOUI().String(),
+		PowerClass:           int(rom.GetPowerClass()),
+		PowerClassWatts:      rom.GetPowerClass().GetMaxPower(),
+		SignalingRate:        rom.GetSignalingRate(),
+		Wavelength:           rom.GetWavelength(),
+		SupportedLinkLengths: rom.GetSupportedLinkLengths(),
+		SupportsMonitoring:   rom.SupportsMonitoring(),
+	}
+	if dateCode := rom.GetDateCode(); !dateCode.IsZero() {
+		info.DateCode = dateCode.Format("2006-01-02")
+	}
+	return info
+}
+
+// getModuleMonitoring returns the module wide monitoring values and the given lanes (nil meaning all lanes) of a module
+func getModuleMonitoring(rom eeprom.EEPROM, lanes []int) (*ModuleMonitoring, []Lane) {
+	var monitoring *ModuleMonitoring
+	if rom.SupportsMonitoring() {
+		monitoring = &ModuleMonitoring{}
+		if temperature, err := rom.GetModuleTemperature(); err == nil {
+			monitoring.Temperature = newMeasurement(temperature, nil)
+		}
+		if voltage, err := rom.GetModuleVoltage(); err == nil {
+			monitoring.Voltage = newMeasurement(voltage, nil)
+		}
+	}
+
+	wavelengths := getLaneWavelengths(rom)
+	result := []Lane{}
+	for index, laser := range rom.GetLasers() {
+		if lanes != nil && !containsInt(lanes, index) {
+			continue
+		}
+		lane := Lane{
+			Index:              index,
+			SupportsMonitoring: rom.SupportsMonitoring() && laser.SupportsMonitoring(),
+		}
+		if index < len(wavelengths) && wavelengths[index] > 0 {
+			lane.Wavelength = wavelengths[index]
+		}
+		if lane.SupportsMonitoring {
+			if bias, err := laser.GetBias(); err == nil {
+				lane.BiasCurrent = newMeasurement(bias, nil)
+			}
+			if txPower, err := laser.GetTxPower(); err == nil {
+				lane.TxPower = newMeasurement(txPower, nil)
+				lane.TxPowerDbm = newDbmMeasurement(txPower)
+			}
+			if rxPower, err := laser.GetRxPower(); err == nil {
+				lane.RxPower = newMeasurement(rxPower, nil)
+				lane.RxPowerDbm = newDbmMeasurement(rxPower)
+			}
+		}
+		result = append(result, lane)
+	}
+	return monitoring, result
+}
+
+// newMeasurement converts a measurement read from the EEPROM, applying convert to the value and thresholds if not nil
+func newMeasurement(measurement eeprom.Measurement, convert func(float64) float64) *Measurement {
+	if convert == nil {
+		convert = func(value float64) float64 { return value }
+	}
+	result := &Measurement{Value: convert(measurement.GetValue())}
+	if !measurement.SupportsThresholds() {
+		return result
+	}
+	if thresholds, err := measurement.GetAlarmThresholds(); err == nil {
+		result.Thresholds = &Thresholds{
+			HighAlarm:   convert(thresholds.GetHighAlarm()),
+			HighWarning: convert(thresholds.GetHighWarning()),
+			LowAlarm:    convert(thresholds.GetLowAlarm()),
+			LowWarning:  convert(thresholds.GetLowWarning()),
+		}
+	}
+	return result
+}
+
+// newDbmMeasurement converts a power measurement from milliwatts to dBm. Powers of 0 mW (e.g. dark lanes) have no dBm value,
+// so the measurement is omitted. Thresholds are omitted if any of them is 0 mW.
+func newDbmMeasurement(measurement eeprom.Measurement) *Measurement {
+	if measurement.GetValue() <= 0 {
+		return nil
+	}
+	result := newMeasurement(measurement, milliwattsToDbm)
+	if thresholds := result.Thresholds; thresholds != nil {
+		for _, value := range []float64{thresholds.HighAlarm, thresholds.HighWarning, thresholds.LowAlarm, thresholds.LowWarning} {
+			if math.IsInf(value, 0) || math.IsNaN(value) {
+				result.Thresholds = nil
+				break
+			}
+		}
+	}
+	return result
+}
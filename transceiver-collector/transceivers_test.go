@@ -0,0 +1,99 @@
+package transceivercollector
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/wobcom/go-ethtool"
+	"github.com/wobcom/go-ethtool/eeprom"
+)
+
+// testMeasurement implements eeprom.Measurement in milliwatts
+type testMeasurement struct {
+	value      float64
+	thresholds *testThresholds
+}
+
+func (m testMeasurement) GetValue() float64        { return m.value }
+func (m testMeasurement) GetUnit() string          { return "mW" }
+func (m testMeasurement) SupportsThresholds() bool { return m.thresholds != nil }
+func (m testMeasurement) GetAlarmThresholds() (eeprom.AlarmThresholds, error) {
+	return *m.thresholds, nil
+}
+
+func roundMeasurement(measurement *Measurement) *Measurement {
+	if measurement == nil {
+		return nil
+	}
+	round := func(value float64) float64 { return math.Round(value*100) / 100 }
+	rounded := &Measurement{Value: round(measurement.Value)}
+	if thresholds := measurement.Thresholds; thresholds != nil {
+		rounded.Thresholds = &Thresholds{round(thresholds.HighAlarm), round(thresholds.HighWarning), round(thresholds.LowAlarm), round(thresholds.LowWarning)}
+	}
+	return rounded
+}
+
+func TestNewDbmMeasurement(t *testing.T) {
+	thresholds := &testThresholds{highAlarm: 2, highWarning: 1, lowAlarm: 0.01, lowWarning: 0.1}
+	tests := []struct {
+		name        string
+		measurement testMeasurement
+		expected    *Measurement
+	}{
+		{
+			name:        "with thresholds",
+			measurement: testMeasurement{value: 0.5, thresholds: thresholds},
+			expected:    &Measurement{Value: -3.01, Thresholds: &Thresholds{HighAlarm: 3.01, HighWarning: 0, LowAlarm: -20, LowWarning: -10}},
+		},
+		{
+			name:        "without thresholds",
+			measurement: testMeasurement{value: 1},
+			expected:    &Measurement{Value: 0},
+		},
+		{
+			name:        "dark lane",
+			measurement: testMeasurement{value: 0, thresholds: thresholds},
+		},
+		{
+			name:        "threshold of 0 mW",
+			measurement: testMeasurement{value: 0.5, thresholds: &testThresholds{highAlarm: 2, highWarning: 1}},
+			expected:    &Measurement{Value: -3.01},
+		},
+	}
+	for _, test := range tests {
+		measurement := newDbmMeasurement(test.measurement)
+		if rounded := roundMeasurement(measurement); !reflect.DeepEqual(rounded, test.expected) {
+			t.Errorf("%s: newDbmMeasurement() = %+v, expected %+v", test.name, rounded, test.expected)
+		}
+		lane := Lane{TxPower: newMeasurement(test.measurement, nil), TxPowerDbm: measurement}
+		if _, err := json.Marshal(lane); err != nil {
+			t.Errorf("%s: could not encode lane: %v", test.name, err)
+		}
+	}
+}
+
+func TestNewDriverInfo(t *testing.T) {
+	driverInfo := newDriverInfo(&ethtool.DriverInfo{
+		DriverName:    "mlx5_core",
+		DriverVersion: "5.15.0\x00.0-generic",
+		BusInfo:       "0000:03:00.0\x00\x00\x00",
+	})
+	expected := &DriverInfo{Name: "mlx5_core", Version: "5.15.0", BusInfo: "0000:03:00.0"}
+	if !reflect.DeepEqual(driverInfo, expected) {
+		t.Errorf("newDriverInfo() = %+v, expected %+v", driverInfo, expected)
+	}
+}
+
+func TestTransceiversOfMissingNetworkNamespace(t *testing.T) {
+	collector := NewCollector(Config{NetworkNamespaces: []string{"transceiver-exporter-test-missing"}})
+	transceivers, errs := collector.Transceivers()
+	// the exporter's own namespace is still read
+	if len(errs) != 1 || errs[0].Netns != "transceiver-exporter-test-missing" {
+		t.Errorf("Transceivers() errors = %+v, expected only one of the missing network namespace", errs)
+	}
+	if transceivers == nil {
+		t.Error("Transceivers() returned no transceivers at all")
+	}
+}
@@ -0,0 +1,168 @@
+package transceivercollector
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// translatedDesc is a descriptor as exported according to the configured schema, namespace and interface labels
+type translatedDesc struct {
+	desc  *prometheus.Desc
+	scale float64
+	// names of the interface labels appended to the metric's own labels
+	extraLabels []string
+}
+
+// metricTranslator rewrites metrics created with schema 1 names according to the configured schema and
+// namespace and attaches the configured interface labels and the network namespace of the interface
+type metricTranslator struct {
+	namespace       string
+	version         int
+	legacy          bool
+	interfaceLabels *InterfaceLabels
+	netnsLabel      bool
+
+	mu    sync.Mutex
+	cache map[*prometheus.Desc][]translatedDesc
+}
+
+func newMetricTranslator(namespace string, version int, legacy bool, interfaceLabels *InterfaceLabels, netnsLabel bool) *metricTranslator {
+	if len(namespace) == 0 {
+		namespace = DefaultNamespace
+	}
+	if version == 0 {
+		version = MetricSchemaLegacy
+	}
+	return &metricTranslator{
+		namespace:       namespace,
+		version:         version,
+		legacy:          legacy,
+		interfaceLabels: interfaceLabels,
+		netnsLabel:      netnsLabel,
+		cache:           make(map[*prometheus.Desc][]translatedDesc),
+	}
+}
+
+// isIdentity returns true if metrics are exported as they are created
+func (m *metricTranslator) isIdentity() bool {
+	return m.namespace+"_" == prefix && m.version == MetricSchemaLegacy && m.interfaceLabels.isEmpty() && !m.netnsLabel
+}
+
+func (m *metricTranslator) translate(desc *prometheus.Desc) []translatedDesc {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if translated, found := m.cache[desc]; found {
+		return translated
+	}
+	metadata, found := descMetadataByDesc[desc]
+	if !found {
+		return []translatedDesc{{desc: desc, scale: 1}}
+	}
+
+	labels := metadata.labels
+	extraLabels := []string{}
+	if contains(metadata.labels, "interface") {
+		if m.netnsLabel && !contains(metadata.labels, "netns") {
+			extraLabels = append(extraLabels, "netns")
+		}
+		for _, name := range m.interfaceLabels.labelNames() {
+			// labels of the collector take precedence
+			if !contains(metadata.labels, name) && !contains(extraLabels, name) {
+				extraLabels = append(extraLabels, name)
+			}
+		}
+		labels = append(append([]string{}, metadata.labels...), extraLabels...)
+	}
+
+	translated := []translatedDesc{}
+	conventional, renamed := conventionalNames[metadata.name]
+	if m.version == MetricSchemaConventional && renamed {
+		help := metadata.help
+		if len(conventional.fromUnit) > 0 {
+			help = strings.Replace(help, conventional.fromUnit, conventional.toUnit, -1)
+		}
+		translated = append(translated, translatedDesc{
+			desc:        prometheus.NewDesc(m.namespace+"_"+conventional.name, help, labels, nil),
+			scale:       conventional.scale,
+			extraLabels: extraLabels,
+		})
+	}
+	if m.version == MetricSchemaLegacy || !renamed || m.legacy {
+		translated = append(translated, translatedDesc{
+			desc:        prometheus.NewDesc(m.namespace+"_"+metadata.name, metadata.help, labels, nil),
+			scale:       1,
+			extraLabels: extraLabels,
+		})
+	}
+	m.cache[desc] = translated
+	return translated
+}
+
+func (m *metricTranslator) describe(descs <-chan *prometheus.Desc, ch chan<- *prometheus.Desc) {
+	for desc := range descs {
+		if m.isIdentity() {
+			ch <- desc
+			continue
+		}
+		for _, translated := range m.translate(desc) {
+			ch <- translated.desc
+		}
+	}
+}
+
+// collect translates the metrics of the interfaces of the network namespace netns
+func (m *metricTranslator) collect(netns string, metrics <-chan prometheus.Metric, ch chan<- prometheus.Metric, errs chan error) {
+	for metric := range metrics {
+		metadata, found := descMetadataByDesc[metric.Desc()]
+		if m.isIdentity() || !found {
+			ch <- metric
+			continue
+		}
+
+		var pb dto.Metric
+		if err := metric.Write(&pb); err != nil {
+			errs <- err
+			continue
+		}
+		valueType := prometheus.GaugeValue
+		value := pb.GetGauge().GetValue()
+		if pb.Counter != nil {
+			valueType = prometheus.CounterValue
+			value = pb.GetCounter().GetValue()
+		}
+		labelValues := make(map[string]string, len(pb.GetLabel()))
+		for _, label := range pb.GetLabel() {
+			labelValues[label.GetName()] = label.GetValue()
+		}
+
+		values := make([]string, len(metadata.labels))
+		for index, label := range metadata.labels {
+			values[index] = labelValues[label]
+		}
+		for _, translated := range m.translate(metric.Desc()) {
+			translatedValues := values
+			if len(translated.extraLabels) > 0 {
+				interfaceLabels := m.interfaceLabels.labelsFor(labelValues["interface"])
+				translatedValues = append([]string{}, values...)
+				for _, name := range translated.extraLabels {
+					if name == "netns" && m.netnsLabel {
+						translatedValues = append(translatedValues, netns)
+						continue
+					}
+					translatedValues = append(translatedValues, interfaceLabels[name])
+				}
+			}
+			translatedMetric, err := prometheus.NewConstMetric(translated.desc, valueType, value*translated.scale, translatedValues...)
+			if err != nil {
+				errs <- fmt.Errorf("Could not translate metric %s: %v", metadata.name, err)
+				continue
+			}
+			ch <- translatedMetric
+		}
+	}
+}
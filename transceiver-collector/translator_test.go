@@ -0,0 +1,244 @@
+package transceivercollector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// testInfoDesc is a metric without interface label, which never carries interface labels
+var testInfoDesc = newDesc("test_info", "Test metric in milliwatts without interface", []string{"version"})
+
+// translatorTestCollector exports the given metrics through a metricTranslator
+type translatorTestCollector struct {
+	translator *metricTranslator
+	netns      string
+	metrics    []prometheus.Metric
+}
+
+func (c *translatorTestCollector) Describe(ch chan<- *prometheus.Desc) {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		defer close(descs)
+		for _, metric := range c.metrics {
+			descs <- metric.Desc()
+		}
+	}()
+	c.translator.describe(descs, ch)
+}
+
+func (c *translatorTestCollector) Collect(ch chan<- prometheus.Metric) {
+	metrics := make(chan prometheus.Metric)
+	errs := make(chan error, len(c.metrics)*2)
+	go func() {
+		defer close(metrics)
+		for _, metric := range c.metrics {
+			metrics <- metric
+		}
+	}()
+	c.translator.collect(c.netns, metrics, ch, errs)
+	close(errs)
+	for err := range errs {
+		panic(err)
+	}
+}
+
+// gatherTranslated translates the metrics and returns them as sorted `name{label="value",...} value` lines,
+// along with the help texts by metric name
+func gatherTranslated(t *testing.T, translator *metricTranslator, netns string, metrics ...prometheus.Metric) ([]string, map[string]string) {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(&translatorTestCollector{translator, netns, metrics})
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{}
+	help := map[string]string{}
+	for _, family := range families {
+		help[family.GetName()] = family.GetHelp()
+		for _, metric := range family.GetMetric() {
+			labels := []string{}
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+			}
+			value := metric.GetGauge().GetValue()
+			if metric.Counter != nil {
+				value = metric.GetCounter().GetValue()
+			}
+			lines = append(lines, fmt.Sprintf("%s{%s} %g", family.GetName(), strings.Join(labels, ","), value))
+		}
+	}
+	sort.Strings(lines)
+	return lines, help
+}
+
+func TestMetricTranslator(t *testing.T) {
+	testLabels := &InterfaceLabels{
+		names: []string{"port", "site"},
+		rules: []interfaceLabelRule{
+			{name: "swp1", labels: map[string]string{"site": "fra1", "port": "cage1"}},
+		},
+		cache: make(map[string]map[string]string),
+	}
+	metrics := func() []prometheus.Metric {
+		return []prometheus.Metric{
+			prometheus.MustNewConstMetric(laserTxPowerDescMw, prometheus.GaugeValue, 0.5, "swp1", "swp1", "0"),
+			prometheus.MustNewConstMetric(laserBiasDesc, prometheus.GaugeValue, 40, "swp1", "swp1", "0"),
+			prometheus.MustNewConstMetric(moduleSupportsMonitoringDesc, prometheus.GaugeValue, 1, "swp1"),
+			prometheus.MustNewConstMetric(linkSpeedDesc, prometheus.GaugeValue, 100000, "swp2"),
+			prometheus.MustNewConstMetric(carrierChangesDesc, prometheus.CounterValue, 3, "swp1"),
+			prometheus.MustNewConstMetric(testInfoDesc, prometheus.GaugeValue, 1, "1.0"),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		translator *metricTranslator
+		netns      string
+		lines      []string
+	}{
+		{
+			name:       "schema 1",
+			translator: newMetricTranslator("", MetricSchemaLegacy, false, nil, false),
+			lines: []string{
+				`transceiver_carrier_changes_total{interface="swp1"} 3`,
+				`transceiver_laser_bias_current_milliamperes{interface="swp1",laser_index="0",port="swp1"} 40`,
+				`transceiver_laser_tx_power_milliwatts{interface="swp1",laser_index="0",port="swp1"} 0.5`,
+				`transceiver_link_speed_megabits_per_second{interface="swp2"} 100000`,
+				`transceiver_module_supports_monitoring_bool{interface="swp1"} 1`,
+				`transceiver_test_info{version="1.0"} 1`,
+			},
+		},
+		{
+			name:       "schema 1 with namespace",
+			translator: newMetricTranslator("optics", MetricSchemaLegacy, false, nil, false),
+			lines: []string{
+				`optics_carrier_changes_total{interface="swp1"} 3`,
+				`optics_laser_bias_current_milliamperes{interface="swp1",laser_index="0",port="swp1"} 40`,
+				`optics_laser_tx_power_milliwatts{interface="swp1",laser_index="0",port="swp1"} 0.5`,
+				`optics_link_speed_megabits_per_second{interface="swp2"} 100000`,
+				`optics_module_supports_monitoring_bool{interface="swp1"} 1`,
+				`optics_test_info{version="1.0"} 1`,
+			},
+		},
+		{
+			name:       "schema 2 renames and scales to base units",
+			translator: newMetricTranslator("", MetricSchemaConventional, false, nil, false),
+			lines: []string{
+				`transceiver_carrier_changes_total{interface="swp1"} 3`,
+				`transceiver_laser_bias_current_amperes{interface="swp1",laser_index="0",port="swp1"} 0.04`,
+				`transceiver_laser_tx_power_watts{interface="swp1",laser_index="0",port="swp1"} 0.0005`,
+				`transceiver_link_speed_bits_per_second{interface="swp2"} 1e+11`,
+				`transceiver_module_supports_monitoring{interface="swp1"} 1`,
+				`transceiver_test_info{version="1.0"} 1`,
+			},
+		},
+		{
+			name:       "schema 2 with legacy names",
+			translator: newMetricTranslator("", MetricSchemaConventional, true, nil, false),
+			lines: []string{
+				`transceiver_carrier_changes_total{interface="swp1"} 3`,
+				`transceiver_laser_bias_current_amperes{interface="swp1",laser_index="0",port="swp1"} 0.04`,
+				`transceiver_laser_bias_current_milliamperes{interface="swp1",laser_index="0",port="swp1"} 40`,
+				`transceiver_laser_tx_power_milliwatts{interface="swp1",laser_index="0",port="swp1"} 0.5`,
+				`transceiver_laser_tx_power_watts{interface="swp1",laser_index="0",port="swp1"} 0.0005`,
+				`transceiver_link_speed_bits_per_second{interface="swp2"} 1e+11`,
+				`transceiver_link_speed_megabits_per_second{interface="swp2"} 100000`,
+				`transceiver_module_supports_monitoring_bool{interface="swp1"} 1`,
+				`transceiver_module_supports_monitoring{interface="swp1"} 1`,
+				`transceiver_test_info{version="1.0"} 1`,
+			},
+		},
+		{
+			name:       "interface labels are appended, labels of the metric take precedence",
+			translator: newMetricTranslator("", MetricSchemaLegacy, false, testLabels, false),
+			lines: []string{
+				`transceiver_carrier_changes_total{interface="swp1",port="cage1",site="fra1"} 3`,
+				`transceiver_laser_bias_current_milliamperes{interface="swp1",laser_index="0",port="swp1",site="fra1"} 40`,
+				`transceiver_laser_tx_power_milliwatts{interface="swp1",laser_index="0",port="swp1",site="fra1"} 0.5`,
+				`transceiver_link_speed_megabits_per_second{interface="swp2",port="",site=""} 100000`,
+				`transceiver_module_supports_monitoring_bool{interface="swp1",port="cage1",site="fra1"} 1`,
+				`transceiver_test_info{version="1.0"} 1`,
+			},
+		},
+		{
+			name:       "netns label",
+			translator: newMetricTranslator("", MetricSchemaConventional, false, testLabels, true),
+			netns:      "blue",
+			lines: []string{
+				`transceiver_carrier_changes_total{interface="swp1",netns="blue",port="cage1",site="fra1"} 3`,
+				`transceiver_laser_bias_current_amperes{interface="swp1",laser_index="0",netns="blue",port="swp1",site="fra1"} 0.04`,
+				`transceiver_laser_tx_power_watts{interface="swp1",laser_index="0",netns="blue",port="swp1",site="fra1"} 0.0005`,
+				`transceiver_link_speed_bits_per_second{interface="swp2",netns="blue",port="",site=""} 1e+11`,
+				`transceiver_module_supports_monitoring{interface="swp1",netns="blue",port="cage1",site="fra1"} 1`,
+				`transceiver_test_info{version="1.0"} 1`,
+			},
+		},
+	}
+	for _, test := range tests {
+		lines, _ := gatherTranslated(t, test.translator, test.netns, metrics()...)
+		if strings.Join(lines, "\n") != strings.Join(test.lines, "\n") {
+			t.Errorf("%s: translated metrics\n%s\nexpected\n%s", test.name, strings.Join(lines, "\n"), strings.Join(test.lines, "\n"))
+		}
+	}
+}
+
+func TestMetricTranslatorHelp(t *testing.T) {
+	translator := newMetricTranslator("", MetricSchemaConventional, true, nil, false)
+	_, help := gatherTranslated(t, translator, "",
+		prometheus.MustNewConstMetric(laserTxPowerDescMw, prometheus.GaugeValue, 0.5, "swp1", "swp1", "0"),
+		prometheus.MustNewConstMetric(testInfoDesc, prometheus.GaugeValue, 1, "1.0"),
+	)
+	expected := map[string]string{
+		"transceiver_laser_tx_power_watts":      "Laser tx power in watts",
+		"transceiver_laser_tx_power_milliwatts": "Laser tx power in milliwatts",
+		// metrics not renamed keep their help even if it mentions a unit
+		"transceiver_test_info": "Test metric in milliwatts without interface",
+	}
+	for name, text := range expected {
+		if help[name] != text {
+			t.Errorf("help of %s = %q, expected %q", name, help[name], text)
+		}
+	}
+}
+
+func TestMetricTranslatorIdentity(t *testing.T) {
+	tests := []struct {
+		translator *metricTranslator
+		identity   bool
+	}{
+		{newMetricTranslator("", 0, false, nil, false), true},
+		{newMetricTranslator(DefaultNamespace, MetricSchemaLegacy, true, &InterfaceLabels{}, false), true},
+		{newMetricTranslator("optics", MetricSchemaLegacy, false, nil, false), false},
+		{newMetricTranslator("", MetricSchemaConventional, false, nil, false), false},
+		{newMetricTranslator("", MetricSchemaLegacy, false, &InterfaceLabels{names: []string{"site"}}, false), false},
+		{newMetricTranslator("", MetricSchemaLegacy, false, nil, true), false},
+	}
+	for index, test := range tests {
+		if identity := test.translator.isIdentity(); identity != test.identity {
+			t.Errorf("test %d: isIdentity() = %t, expected %t", index, identity, test.identity)
+		}
+	}
+}
+
+func TestConventionalNames(t *testing.T) {
+	names := map[string]bool{}
+	for _, metadata := range descMetadataByDesc {
+		names[metadata.name] = true
+	}
+	for legacy, conventional := range conventionalNames {
+		if !names[legacy] {
+			t.Errorf("renamed metric %s does not exist", legacy)
+		}
+		if names[conventional.name] {
+			t.Errorf("schema 2 name %s of %s collides with a schema 1 name", conventional.name, legacy)
+		}
+		if conventional.scale == 0 {
+			t.Errorf("schema 2 name %s of %s has a scale of 0", conventional.name, legacy)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package transceivercollector
 
-import "math"
+import (
+	"math"
+	"strings"
+)
 
 func contains(l []string, test string) bool {
 	for _, item := range l {
@@ -11,6 +14,24 @@ func contains(l []string, test string) bool {
 	return false
 }
 
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(l []int, test int) bool {
+	for _, item := range l {
+		if item == test {
+			return true
+		}
+	}
+	return false
+}
+
 func boolToFloat64(b bool) float64 {
 	if b {
 		return 1
@@ -21,3 +42,21 @@ func boolToFloat64(b bool) float64 {
 func milliwattsToDbm(mw float64) float64 {
 	return 10 * math.Log10(mw)
 }
+
+func isZero(address []byte) bool {
+	for _, b := range address {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateAtNUL cuts a string read from a fixed size kernel buffer at its first NUL byte.
+// Drivers overwriting strings pre-filled by the kernel (e.g. the driver version) leave the remainder behind it.
+func truncateAtNUL(s string) string {
+	if index := strings.IndexByte(s, 0); index >= 0 {
+		return s[:index]
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package transceivercollector
+
+import (
+	"github.com/wobcom/go-ethtool/eeprom"
+	"github.com/wobcom/go-ethtool/eeprom/sff8024"
+	"github.com/wobcom/go-ethtool/eeprom/sff8636"
+)
+
+// Nominal lane wavelengths in nanometers of common WDM grids
+var (
+	cwdm4Grid   = []float64{1271, 1291, 1311, 1331}
+	lanWdm4Grid = []float64{1295.56, 1300.05, 1304.58, 1309.14}
+	swdm4Grid   = []float64{850, 880, 910, 940}
+)
+
+// extendedComplianceLaneWavelengths maps SFF-8024 extended compliance codes of four lane WDM modules to their grid
+var extendedComplianceLaneWavelengths = map[sff8024.ExtendedSpecificationCompliance][]float64{
+	sff8024.ExtendedSpecificationCompliance100GBaseLR425GBaseLR: lanWdm4Grid,
+	sff8024.ExtendedSpecificationCompliance100GBaseER425GBaseER: lanWdm4Grid,
+	sff8024.ExtendedSpecificationCompliance100GCWDM4:            cwdm4Grid,
+	sff8024.ExtendedSpecificationCompliance40GBaseER4:           cwdm4Grid,
+	sff8024.ExtendedSpecificationCompliance100GCLR4:             cwdm4Grid,
+	sff8024.ExtendedSpecificationCompliance40GSWDM4:             swdm4Grid,
+	sff8024.ExtendedSpecificationCompliance100GSWDM4:            swdm4Grid,
+	sff8024.ExtendedSpecificationCompliance4WDM10MSA:            cwdm4Grid,
+	sff8024.ExtendedSpecificationCompliance4WDM20:               lanWdm4Grid,
+	sff8024.ExtendedSpecificationCompliance4WDM40:               lanWdm4Grid,
+	sff8024.ExtendedSpecificationCompliance200GBaseFR4:          cwdm4Grid,
+	sff8024.ExtendedSpecificationCompliance200GBaseLR4:          lanWdm4Grid,
+}
+
+// getLaneWavelengths returns the nominal wavelength of every lane of a module.
+// Four lane WDM modules are looked up by their compliance code, all other modules
+// use the single wavelength reported by the module for all of their lanes.
+func getLaneWavelengths(rom eeprom.EEPROM) []float64 {
+	laserCount := len(rom.GetLasers())
+	if qsfp, ok := rom.(*sff8636.EEPROM); ok && laserCount == 4 {
+		if qsfp.SpecificationCompliance[sff8636.Spec40GBaseLR4] {
+			return cwdm4Grid
+		}
+		if grid, found := extendedComplianceLaneWavelengths[qsfp.ExtendedSpecificationCompliance]; found {
+			return grid
+		}
+	}
+
+	wavelengths := make([]float64, laserCount)
+	for index := range wavelengths {
+		wavelengths[index] = rom.GetWavelength()
+	}
+	return wavelengths
+}
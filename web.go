@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// logrusLogger passes messages of the exporter-toolkit (logging using go-kit key value pairs) to logrus
+type logrusLogger struct{}
+
+func (logrusLogger) Log(keyvals ...interface{}) error {
+	fields := log.Fields{}
+	message := ""
+	level := log.InfoLevel
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		switch key {
+		case "msg":
+			message = fmt.Sprint(keyvals[i+1])
+		case "level":
+			if parsed, err := log.ParseLevel(fmt.Sprint(keyvals[i+1])); err == nil {
+				level = parsed
+			}
+		default:
+			fields[key] = keyvals[i+1]
+		}
+	}
+	log.WithFields(fields).Log(level, message)
+	return nil
+}